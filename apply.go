@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"yamldiff/compare"
+)
+
+// edit is a change located in the node tree it applies to
+type edit struct {
+	change compare.Change
+	// parent is the mapping or sequence holding the changed value
+	parent *yamlv3.Node
+	// key and value are the entry of an existing value, key is nil for list
+	// elements
+	key, value *yamlv3.Node
+	// segment is the last segment of the path of the change
+	segment string
+}
+
+// childNode returns the key and value of a map entry written in the mapping
+// itself, or the list element selected by a path segment
+func childNode(node *yamlv3.Node, segment string) (key, value *yamlv3.Node) {
+	if strings.HasPrefix(segment, "[") {
+		return nil, compare.ElementNode(node, segment)
+	}
+	if node.Kind != yamlv3.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if k, err := compare.ScalarValue(node.Content[i]); err == nil && node.Content[i].Tag != "!!merge" && fmt.Sprint(k) == segment {
+			return node.Content[i], node.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// sameJSON reports whether two values are equal once converted to JSON
+// types, so values read back from a saved result compare equal to the
+// values of a file
+func sameJSON(a, b interface{}) bool {
+	dataA, errA := json.Marshal(compare.JSONValue(a))
+	dataB, errB := json.Marshal(compare.JSONValue(b))
+	return errA == nil && errB == nil && bytes.Equal(dataA, dataB)
+}
+
+// locateChange finds the nodes a change applies to. Values that are modified
+// or removed must still hold the value the change was found with, and added
+// values must not exist yet.
+func locateChange(root *yamlv3.Node, ch compare.Change) (*edit, error) {
+	segments := compare.SplitPath(ch.Path)
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("%s: the whole document cannot be changed", ch.Path)
+	}
+
+	parent := root
+	for _, segment := range segments[:len(segments)-1] {
+		if _, parent = childNode(parent, segment); parent == nil {
+			return nil, fmt.Errorf("%s: not found in the file", ch.Path)
+		}
+	}
+	e := &edit{change: ch, parent: parent, segment: segments[len(segments)-1]}
+	if parent.Kind != yamlv3.MappingNode && parent.Kind != yamlv3.SequenceNode {
+		return nil, fmt.Errorf("%s: not found in the file", ch.Path)
+	}
+	e.key, e.value = childNode(parent, e.segment)
+
+	switch {
+	case ch.Kind == compare.Added && e.value != nil:
+		return nil, fmt.Errorf("%s: already in the file", ch.Path)
+	case ch.Kind != compare.Added && e.value == nil:
+		return nil, fmt.Errorf("%s: not found in the file", ch.Path)
+	case ch.Kind != compare.Added:
+		val, err := compare.NodeValue(e.value, false)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", ch.Path, err)
+		}
+		if !sameJSON(val, ch.Left) {
+			return nil, fmt.Errorf("%s: is %s in the file instead of %s", ch.Path, compare.InlineValue(val), compare.InlineValue(ch.Left))
+		}
+	}
+	return e, nil
+}
+
+// keyNode returns the node of a map key named by a path segment. It is plain
+// when it reads back as the same segment and a quoted string otherwise.
+func keyNode(segment string) *yamlv3.Node {
+	node := &yamlv3.Node{Kind: yamlv3.ScalarNode, Value: segment}
+	if val, err := compare.ScalarValue(node); err != nil || fmt.Sprint(val) != segment || compare.IsCollection(val) {
+		node.Tag, node.Style = "!!str", yamlv3.DoubleQuotedStyle
+	}
+	return node
+}
+
+// indexOf returns the position of a node in a list of nodes, or -1
+func indexOf(nodes []*yamlv3.Node, node *yamlv3.Node) int {
+	for i, n := range nodes {
+		if n == node {
+			return i
+		}
+	}
+	return -1
+}
+
+// applyEdit changes the node tree as described by an edit. Replaced values
+// keep their comments.
+func applyEdit(e *edit) error {
+	ch := e.change
+	if ch.Kind == compare.Removed {
+		i := indexOf(e.parent.Content, e.value)
+		if e.key != nil {
+			i--
+			e.parent.Content = append(e.parent.Content[:i], e.parent.Content[i+2:]...)
+		} else {
+			e.parent.Content = append(e.parent.Content[:i], e.parent.Content[i+1:]...)
+		}
+		return nil
+	}
+
+	node, err := compare.ValueNode(ch.Right)
+	if err != nil {
+		return fmt.Errorf("%s: %v", ch.Path, err)
+	}
+	if ch.Kind == compare.Modified {
+		node.HeadComment, node.LineComment, node.FootComment = e.value.HeadComment, e.value.LineComment, e.value.FootComment
+		e.parent.Content[indexOf(e.parent.Content, e.value)] = node
+		return nil
+	}
+
+	if e.parent.Kind == yamlv3.MappingNode {
+		e.parent.Content = append(e.parent.Content, keyNode(e.segment), node)
+	} else {
+		e.parent.Content = append(e.parent.Content, node)
+	}
+	return nil
+}
+
+// applyChanges applies the changes of a comparison to a YAML file holding the
+// first compared file, turning the first document into the second one while
+// keeping comments and the other documents. Every change is located before
+// anything is changed, so changes to list elements do not shift each other,
+// and nothing is applied unless all of them apply.
+func applyChanges(data []byte, changes []compare.Change) ([]byte, error) {
+	docs, err := compare.DecodeDocuments(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		docs = []*yamlv3.Node{{Kind: yamlv3.DocumentNode}}
+	}
+	root := documentRoot(docs[0])
+	if root == nil {
+		root = &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+		docs[0].Content = []*yamlv3.Node{root}
+	}
+
+	var edits []*edit
+	var problems []string
+	for _, ch := range changes {
+		e, err := locateChange(root, ch)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		edits = append(edits, e)
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%d changes do not apply:\n  %s", len(problems), strings.Join(problems, "\n  "))
+	}
+	for _, e := range edits {
+		if err := applyEdit(e); err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := yamlv3.NewEncoder(&buf)
+	enc.SetIndent(indentOf(data))
+	for _, doc := range docs {
+		if err := enc.Encode(doc); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+
+	written, err := compare.DecodeDocuments(buf.Bytes())
+	if err != nil || len(written) != len(docs) || !sameNode(documentRoot(written[0]), root) {
+		return nil, fmt.Errorf("the changed file does not read back as the changed values")
+	}
+	return buf.Bytes(), nil
+}
+
+// newApplyCmd returns the apply command applying a saved result to a file
+func newApplyCmd() *cobra.Command {
+	var write bool
+	var fileName string
+
+	cmd := &cobra.Command{
+		Use:   "apply result.json file.yaml",
+		Short: "Apply the changes of a saved result to a file.",
+		Long: `apply applies the changes of a result saved with --save-result to a file
+holding the first compared file, so it ends up with the values of the second
+one, keeping comments and key order:
+
+    yamldiff staging.yaml production.yaml --save-result promote.json
+    yamldiff apply promote.json canary.yaml -w
+
+Every changed or removed value must still be the one the result was computed
+with, and added values must not exist yet; otherwise nothing is applied.
+Results of diff skip keys found in only one file, while results of pr and
+batch include them. Results of several files need --file to select one by
+its path. The changed file is printed, or written back with -w.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			result, err := loadResult(args[0])
+			if err != nil {
+				return fmt.Errorf("error loading result: %v", err)
+			}
+			var selected *fileReport
+			for i, f := range result.Report.Files {
+				if fileName == "" && len(result.Report.Files) == 1 || fileName != "" && f.Path == fileName {
+					selected = &result.Report.Files[i]
+				}
+			}
+			switch {
+			case selected == nil && fileName == "":
+				return fmt.Errorf("the result holds %d files, select one with --file", len(result.Report.Files))
+			case selected == nil:
+				return fmt.Errorf("the result holds no file %s", fileName)
+			case selected.Error != "":
+				return fmt.Errorf("the comparison of %s failed: %s", selected.Path, selected.Error)
+			}
+
+			data, err := ioutil.ReadFile(args[1])
+			if err != nil {
+				return fmt.Errorf("error loading file: %v", err)
+			}
+			changed, err := applyChanges(data, selected.Changes)
+			if err != nil {
+				return fmt.Errorf("error applying %s to %s: %v", args[0], args[1], err)
+			}
+
+			verbosef(1, "applied %d changes to %s", len(selected.Changes), args[1])
+			if write {
+				return ioutil.WriteFile(args[1], changed, 0644)
+			}
+			_, err = os.Stdout.Write(changed)
+			return err
+		},
+	}
+
+	cmd.Flags().BoolVarP(&write, "write", "w", false, "Write the changed content back to the file.")
+	cmd.Flags().StringVar(&fileName, "file", "", "Path of the file in the result whose changes are applied.")
+	return cmd
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"yamldiff/compare"
+)
+
+func TestApplyChanges(t *testing.T) {
+	left := `# service
+name: svc # the name
+replicas: 2
+hosts: [a, b, a]
+containers:
+  - name: web
+    image: web:1 # pinned
+  - name: db
+    image: db:1
+env:
+  LOG: info
+  OLD: x
+---
+other: document
+`
+	right := `name: svc
+replicas: 3
+hosts: [b, c]
+containers:
+  - name: db
+    image: db:2
+  - name: web
+    image: web:1
+  - name: cache
+    image: redis
+env:
+  LOG: warn
+  2: 3
+  "yes": 1
+`
+	want := `# service
+name: svc # the name
+replicas: 3
+hosts: [b, c]
+containers:
+  - name: web
+    image: web:1 # pinned
+  - name: db
+    image: db:2
+  - image: redis
+    name: cache
+env:
+  LOG: warn
+  2: 3
+  "yes": 1
+---
+other: document
+`
+
+	changes, err := compare.Documents([]byte(left), []byte(right), compare.Options{MissingKeys: true, ScalarLists: "multiset"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := applyChanges([]byte(left), changes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("applied =\n%s\nwant\n%s", got, want)
+	}
+
+	// The result holds the values of the second file
+	again, err := compare.Documents(got, []byte(right), compare.Options{MissingKeys: true, ScalarLists: "multiset"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(again) > 0 {
+		t.Errorf("changes after applying = %v, want none", changePaths(again))
+	}
+}
+
+func TestApplyChangesChecksValues(t *testing.T) {
+	changes := []compare.Change{
+		{Path: ".a", Kind: compare.Modified, Left: 1, Right: 2},
+		{Path: ".b", Kind: compare.Removed, Left: "x"},
+		{Path: ".c", Kind: compare.Added, Right: true},
+		{Path: ".d.e", Kind: compare.Modified, Left: 1, Right: 2},
+	}
+
+	_, err := applyChanges([]byte("a: 5\nb: z\nc: false\n"), changes)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{"4 changes do not apply", ".a: is 5", ".b: is z", ".c: already in the file", ".d.e: not found"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err, want)
+		}
+	}
+
+	got, err := applyChanges([]byte("a: 1 # one\nb: x\n"), changes[:3])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "a: 2 # one\nc: true\n"; string(got) != want {
+		t.Errorf("applied = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	"yamldiff/compare"
+)
+
+// pair is an entry of a batch manifest: two files compared with their own
+// options
+type pair struct {
+	Name    string            `yaml:"name"`
+	Left    string            `yaml:"left"`
+	Right   string            `yaml:"right"`
+	Profile string            `yaml:"profile"`
+	Ignore  []string          `yaml:"ignore"`
+	Subset  bool              `yaml:"subset"`
+	Labels  map[string]string `yaml:"labels"`
+}
+
+// batchManifest is the content of a batch manifest file
+type batchManifest struct {
+	Profiles []profile `yaml:"profiles"`
+	Pairs    []pair    `yaml:"pairs"`
+}
+
+// loadManifest loads a batch manifest and checks that every pair names two
+// files and an existing profile
+func loadManifest(filePath string, cfg *config) (*batchManifest, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest batchManifest
+	if err := yaml.UnmarshalStrict(data, &manifest); err != nil {
+		return nil, err
+	}
+
+	// Profiles of the manifest take precedence over the configuration file
+	cfg.Profiles = append(manifest.Profiles, cfg.Profiles...)
+	for i, p := range manifest.Pairs {
+		if p.Left == "" || p.Right == "" {
+			return nil, fmt.Errorf("pair %d needs a left and a right file", i+1)
+		}
+		if p.Profile != "" && cfg.profileNamed(p.Profile) == nil {
+			return nil, fmt.Errorf("pair %d uses unknown profile %q", i+1, p.Profile)
+		}
+	}
+	return &manifest, nil
+}
+
+// comparePair compares the two files of a pair. Relative paths are resolved
+// against baseDir.
+func comparePair(p pair, baseDir string, cfg *config) fileReport {
+	resolve := func(name string) string {
+		if filepath.IsAbs(name) {
+			return name
+		}
+		return filepath.Join(baseDir, name)
+	}
+
+	result := fileReport{Path: p.Name, OldPath: p.Left, Status: "modified", Profile: p.Profile, Labels: p.Labels, Changes: []compare.Change{}}
+	if result.Path == "" {
+		result.Path = p.Right
+	}
+
+	result.Options = cfg.profileNamed(p.Profile).options()
+	result.Options.Ignore = append(append([]string{}, result.Options.Ignore...), p.Ignore...)
+	result.Options.MissingKeys = !p.Subset
+	result.Options.Subset = p.Subset
+
+	doc1, err := loadSource(resolve(p.Left), p.Subset)
+	if err != nil {
+		result.Status, result.Error = "error", fmt.Sprintf("error loading %s: %v", p.Left, err)
+		return result
+	}
+	doc2, err := loadSource(resolve(p.Right), false)
+	if err != nil {
+		result.Status, result.Error = "error", fmt.Sprintf("error loading %s: %v", p.Right, err)
+		return result
+	}
+
+	c := &compare.Comparison{Options: result.Options}
+	c.Compare(doc1, doc2)
+	result.Options = c.Options
+	result.Changes = append(result.Changes, c.Changes...)
+	return result
+}
+
+// runBatch compares all pairs using the given number of concurrent workers.
+// The results keep the order of the manifest.
+func runBatch(pairs []pair, baseDir string, cfg *config, jobs int) []fileReport {
+	results := make([]fileReport, len(pairs))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = comparePair(pairs[i], baseDir, cfg)
+			}
+		}()
+	}
+
+	for i := range pairs {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
+
+// formatLabels renders labels as sorted key=value pairs
+func formatLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for key, value := range labels {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ", ")
+}
+
+// newBatchCmd returns the batch command comparing many file pairs listed in a
+// manifest
+func newBatchCmd() *cobra.Command {
+	var outputFormat, saveResultFile string
+	var jobs int
+
+	cmd := &cobra.Command{
+		Use:   "batch pairs.yaml",
+		Short: "Compare many pairs of files listed in a manifest.",
+		Long: `batch compares every pair of files listed in a manifest concurrently and
+prints one consolidated report. Each pair may select a profile, add ignore
+patterns, use subset mode and carry labels that are shown in the report.
+Relative paths are resolved against the directory of the manifest.
+
+    profiles:
+      - name: k8s
+        ignore: [".metadata.annotations", ".status"]
+    pairs:
+      - left: prod/payments.yaml
+        right: rendered/payments.yaml
+        profile: k8s
+        ignore: [".spec.replicas"]
+        labels: {env: prod, team: payments}
+
+Profiles may also come from .yamldiff.yaml or the file given with --config.
+Keys added or removed in a file are reported unless the pair uses subset
+mode. The exit status is 0 when no pair differs, 1 when any pair differs and
+2 when any file could not be compared.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			cfg, err := loadGlobalConfig()
+			if err != nil {
+				return fmt.Errorf("error loading configuration: %v", err)
+			}
+
+			manifest, err := loadManifest(args[0], cfg)
+			if err != nil {
+				return fmt.Errorf("error loading manifest: %v", err)
+			}
+			if jobs < 1 {
+				jobs = 1
+			}
+
+			r := &report{Base: "left", Head: "right"}
+			verbosef(1, "comparing %d pairs with %d jobs", len(manifest.Pairs), jobs)
+			r.Files = runBatch(manifest.Pairs, filepath.Dir(args[0]), cfg, jobs)
+
+			if saveResultFile != "" {
+				if err := saveResult(saveResultFile, "batch", []inputInfo{fileInput(args[0])}, r); err != nil {
+					return fmt.Errorf("error saving result: %v", err)
+				}
+			}
+
+			var output bytes.Buffer
+			p := &printer{w: &output, msg: catalogFor("")}
+			p.color, _ = useColor(globalFlags.color)
+			if err := p.printResult(r, nil, outputFormat); err != nil {
+				return err
+			}
+			if _, err := os.Stdout.Write(output.Bytes()); err != nil {
+				return err
+			}
+
+			exitCode := 0
+			for _, f := range r.Files {
+				if f.Error != "" {
+					exitCode = 2
+				} else if len(f.Changes) > 0 && exitCode == 0 {
+					exitCode = 1
+				}
+			}
+			if exitCode != 0 {
+				os.Exit(exitCode)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "", "Set the output format ("+strings.Join(outputFormats, ", ")+").")
+	cmd.Flags().StringVar(&saveResultFile, "save-result", "", "Write the structured report to this JSON file for \"yamldiff render\".")
+	cmd.Flags().IntVarP(&jobs, "jobs", "j", runtime.NumCPU(), "Number of pairs compared concurrently.")
+	cmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions(outputFormats, cobra.ShellCompDirectiveNoFileComp))
+	return cmd
+}
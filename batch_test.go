@@ -0,0 +1,97 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadManifest(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"valid", "profiles:\n  - name: k8s\n    ignore: [.metadata]\npairs:\n  - left: a.yaml\n    right: b.yaml\n    profile: k8s\n", ""},
+		{"missing right file", "pairs:\n  - left: a.yaml\n", "pair 1 needs a left and a right file"},
+		{"unknown profile", "pairs:\n  - left: a.yaml\n    right: b.yaml\n    profile: helm\n", `pair 1 uses unknown profile "helm"`},
+		{"unknown field", "pairs:\n  - left: a.yaml\n    right: b.yaml\n    jobs: 2\n", "field jobs not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadManifest(writeTemp(t, "batch.yaml", tt.content), &config{})
+			if tt.want == "" && err != nil || tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+				t.Errorf("loadManifest() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunBatch(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"a.yaml":       "name: web\nreplicas: 1\nmetadata:\n  uid: 1\n",
+		"b.yaml":       "name: web\nreplicas: 2\nmetadata:\n  uid: 2\nport: 80\n",
+		"pattern.yaml": "name: web\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &config{Profiles: []profile{{Name: "k8s", Ignore: []string{".metadata"}}}}
+	pairs := []pair{
+		{Name: "web", Left: "a.yaml", Right: "b.yaml", Profile: "k8s", Labels: map[string]string{"env": "prod"}},
+		{Left: "pattern.yaml", Right: "b.yaml", Subset: true},
+		{Left: "a.yaml", Right: "missing.yaml"},
+	}
+	results := runBatch(pairs, dir, cfg, 2)
+
+	if got, want := changePaths(results[0].Changes), []string{".replicas", ".port"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("changes of the first pair = %v, want %v", got, want)
+	}
+	if results[0].Path != "web" || results[0].Profile != "k8s" || results[0].Labels["env"] != "prod" {
+		t.Errorf("first pair reported as %+v", results[0])
+	}
+	if results[1].Path != "b.yaml" || len(results[1].Changes) != 0 {
+		t.Errorf("subset pair reported as %+v, want b.yaml without changes", results[1])
+	}
+	if results[2].Status != "error" || !strings.Contains(results[2].Error, "error loading missing.yaml") {
+		t.Errorf("missing file reported as %+v", results[2])
+	}
+}
+
+func TestFormatLabels(t *testing.T) {
+	if got, want := formatLabels(map[string]string{"team": "payments", "env": "prod"}), "env=prod, team=payments"; got != want {
+		t.Errorf("formatLabels() = %q, want %q", got, want)
+	}
+}
+
+func TestLoadGlobalConfig(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, defaultConfigFile), []byte("profiles:\n  - name: k8s\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	// The configuration in the working directory is used unless --config is given
+	cfg, err := loadGlobalConfig()
+	if err != nil || len(cfg.Profiles) != 1 || cfg.Profiles[0].Name != "k8s" {
+		t.Errorf("loadGlobalConfig() = %+v, %v, want the k8s profile", cfg, err)
+	}
+	globalFlags.config = filepath.Join(dir, "missing.yaml")
+	defer func() { globalFlags.config = "" }()
+	if _, err := loadGlobalConfig(); err == nil {
+		t.Errorf("loadGlobalConfig() with a missing --config file succeeded")
+	}
+}
@@ -0,0 +1,74 @@
+// Command yamldiff-wasm builds package compare as a WebAssembly module with a
+// small API: compare two YAML documents with options and get the changes as
+// JSON. It builds for JavaScript hosts and for WASI runtimes:
+//
+//	GOOS=js GOARCH=wasm go build -o yamldiff.wasm ./cmd/yamldiff-wasm
+//	GOOS=wasip1 GOARCH=wasm go build -o yamldiff-wasi.wasm ./cmd/yamldiff-wasm
+//
+// The js build is loaded with the wasm_exec.js shipped with Go and registers
+// yamldiff.compare(left, right, options), which returns a JSON string holding
+// either the changes or an error:
+//
+//	{"changes": [{"path": ".a", "kind": "modified", "left": 1, "right": 2}]}
+//	{"error": "error parsing first document: ..."}
+//
+// The wasip1 build reads a request from its standard input and writes the same
+// result to its standard output:
+//
+//	{"left": "a: 1\n", "right": "a: 2\n", "options": {"ignore": [".metadata"]}}
+//
+// options has the fields ignore (a list of path patterns), only, listKeys (an
+// object mapping list path patterns to the field identifying their elements),
+// missingKeys, subset, positionalLists and scalarLists ("set" or "multiset"),
+// named like the options of saved results.
+//
+// The tests of the js build run under Node.js with the wrapper shipped with
+// Go, found in misc/wasm instead of lib/wasm before Go 1.24:
+//
+//	GOOS=js GOARCH=wasm go test -exec="$(go env GOROOT)/lib/wasm/go_js_wasm_exec" ./cmd/yamldiff-wasm
+//
+// The native tests run the wasip1 build under the WASI support of Node.js.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"yamldiff/compare"
+)
+
+// request is a comparison read by the wasip1 build
+type request struct {
+	Left    string          `json:"left"`
+	Right   string          `json:"right"`
+	Options compare.Options `json:"options"`
+}
+
+// compareYAML compares two YAML documents and encodes the result as a JSON
+// object holding either the changes or an error
+func compareYAML(left, right string, options compare.Options) string {
+	changes, err := compare.Documents([]byte(left), []byte(right), options)
+	if err != nil {
+		return result("error", err.Error())
+	}
+	return result("changes", append([]compare.Change{}, changes...))
+}
+
+// compareRequest reads a JSON request and compares its documents
+func compareRequest(r io.Reader) string {
+	var req request
+	if err := json.NewDecoder(r).Decode(&req); err != nil {
+		return result("error", fmt.Sprintf("error reading request: %v", err))
+	}
+	return compareYAML(req.Left, req.Right, req.Options)
+}
+
+// result encodes a result as a JSON object with a single field
+func result(field string, val interface{}) string {
+	data, err := json.Marshal(map[string]interface{}{field: val})
+	if err != nil {
+		data, _ = json.Marshal(map[string]string{"error": err.Error()})
+	}
+	return string(data)
+}
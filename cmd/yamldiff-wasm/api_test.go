@@ -0,0 +1,51 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompareRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "modified value",
+			in:   `{"left": "a: 1\n", "right": "a: 2\n"}`,
+			want: `{"changes":[{"path":".a","kind":"modified","left":1,"right":2,` +
+				`"leftRange":{"line":1,"column":1,"endLine":1,"endColumn":5},` +
+				`"rightRange":{"line":1,"column":1,"endLine":1,"endColumn":5}}]}`,
+		},
+		{
+			name: "no changes",
+			in:   `{"left": "a: 1\n", "right": "a: 1\n"}`,
+			want: `{"changes":[]}`,
+		},
+		{
+			name: "options",
+			in:   `{"left": "a: 1\nb: 1\n", "right": "a: 2\n", "options": {"ignore": [".a"], "missingKeys": true}}`,
+			want: `{"changes":[{"path":".b","kind":"removed","left":1,` +
+				`"leftRange":{"line":2,"column":1,"endLine":2,"endColumn":5}}]}`,
+		},
+		{
+			name: "invalid document",
+			in:   `{"left": "a: [\n", "right": "a: 1\n"}`,
+			want: `{"error":"error parsing first document: yaml: line 1: did not find expected node content"}`,
+		},
+		{
+			name: "invalid request",
+			in:   `["a: 1"]`,
+			want: `{"error":"error reading request: json: cannot unmarshal array into Go value of type main.request"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := compareRequest(strings.NewReader(tt.in)); got != tt.want {
+				t.Errorf("compareRequest(%s) =\n%s\nwant\n%s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !js
+
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// main answers a single request on standard input. It is the entry point of
+// the wasip1 build and also runs natively, e.g. to try requests.
+func main() {
+	fmt.Println(compareRequest(os.Stdin))
+}
@@ -0,0 +1,76 @@
+//go:build js
+
+package main
+
+import (
+	"syscall/js"
+
+	"yamldiff/compare"
+)
+
+// wasmOptions reads the comparison options from a JavaScript object
+func wasmOptions(val js.Value) compare.Options {
+	var options compare.Options
+	if val.Type() != js.TypeObject {
+		return options
+	}
+
+	options.Ignore = wasmStrings(val.Get("ignore"))
+	options.Only = wasmStrings(val.Get("only"))
+	if listKeys := val.Get("listKeys"); listKeys.Type() == js.TypeObject {
+		patterns := js.Global().Get("Object").Call("keys", listKeys)
+		options.ListKeys = make(map[string]string, patterns.Length())
+		for i := 0; i < patterns.Length(); i++ {
+			pattern := patterns.Index(i).String()
+			options.ListKeys[pattern] = listKeys.Get(pattern).String()
+		}
+	}
+	options.MissingKeys = val.Get("missingKeys").Truthy()
+	options.Subset = val.Get("subset").Truthy()
+	options.PositionalLists = val.Get("positionalLists").Truthy()
+	if scalarLists := val.Get("scalarLists"); scalarLists.Type() == js.TypeString {
+		options.ScalarLists = scalarLists.String()
+	}
+	return options
+}
+
+// wasmStrings reads a JavaScript array of strings, nil if the value is not an
+// array
+func wasmStrings(val js.Value) []string {
+	if val.Type() != js.TypeObject {
+		return nil
+	}
+
+	var list []string
+	for i := 0; i < val.Length(); i++ {
+		list = append(list, val.Index(i).String())
+	}
+	return list
+}
+
+// wasmCompare implements yamldiff.compare
+func wasmCompare(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return result("error", "compare expects two YAML documents")
+	}
+
+	var options compare.Options
+	if len(args) > 2 {
+		options = wasmOptions(args[2])
+	}
+	return compareYAML(args[0].String(), args[1].String(), options)
+}
+
+// registerAPI exposes the yamldiff object to JavaScript
+func registerAPI() {
+	js.Global().Set("yamldiff", js.ValueOf(map[string]interface{}{
+		"compare": js.FuncOf(wasmCompare),
+	}))
+}
+
+func main() {
+	registerAPI()
+
+	// Keep the module alive so the exported functions stay callable
+	select {}
+}
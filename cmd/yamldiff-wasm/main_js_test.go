@@ -0,0 +1,117 @@
+//go:build js
+
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"syscall/js"
+	"testing"
+
+	"yamldiff/compare"
+)
+
+// compareJS calls yamldiff.compare the way JavaScript code does and decodes
+// its result
+func compareJS(t *testing.T, args ...interface{}) map[string]interface{} {
+	t.Helper()
+	out := js.Global().Get("yamldiff").Call("compare", args...)
+	if out.Type() != js.TypeString {
+		t.Fatalf("compare returned a %s, want a string", out.Type())
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal([]byte(out.String()), &result); err != nil {
+		t.Fatalf("compare returned invalid JSON %q: %v", out.String(), err)
+	}
+	return result
+}
+
+func TestWasmCompare(t *testing.T) {
+	registerAPI()
+
+	tests := []struct {
+		name    string
+		args    []interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{
+			name: "modified value",
+			args: []interface{}{"a: 1\nb: x\n", "a: 2\nb: x\n"},
+			want: []interface{}{map[string]interface{}{
+				"path": ".a", "kind": "modified", "left": 1.0, "right": 2.0,
+				"leftRange":  map[string]interface{}{"line": 1.0, "column": 1.0, "endLine": 1.0, "endColumn": 5.0},
+				"rightRange": map[string]interface{}{"line": 1.0, "column": 1.0, "endLine": 1.0, "endColumn": 5.0},
+			}},
+		},
+		{
+			name: "no changes",
+			args: []interface{}{"a: 1\n", "a: 1\n"},
+			want: []interface{}{},
+		},
+		{
+			name: "ignore and missing keys",
+			args: []interface{}{"a: 1\nb: 1\n", "a: 2\nc: 1\n", map[string]interface{}{
+				"ignore": []interface{}{".a"}, "missingKeys": true,
+			}},
+			want: []interface{}{
+				map[string]interface{}{"path": ".b", "kind": "removed", "left": 1.0,
+					"leftRange": map[string]interface{}{"line": 2.0, "column": 1.0, "endLine": 2.0, "endColumn": 5.0}},
+				map[string]interface{}{"path": ".c", "kind": "added", "right": 1.0,
+					"rightRange": map[string]interface{}{"line": 2.0, "column": 1.0, "endLine": 2.0, "endColumn": 5.0}},
+			},
+		},
+		{
+			name:    "invalid document",
+			args:    []interface{}{"a: [\n", "a: 1\n"},
+			wantErr: true,
+		},
+		{
+			name:    "missing argument",
+			args:    []interface{}{"a: 1\n"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := compareJS(t, tt.args...)
+			if tt.wantErr {
+				if _, ok := result["error"].(string); !ok {
+					t.Errorf("result = %v, want an error", result)
+				}
+				return
+			}
+			if !reflect.DeepEqual(result["changes"], tt.want) {
+				t.Errorf("changes = %#v, want %#v", result["changes"], tt.want)
+			}
+		})
+	}
+}
+
+func TestWasmOptions(t *testing.T) {
+	val := js.Global().Get("JSON").Call("parse", `{
+		"ignore": [".a", ".b"],
+		"only": [".spec"],
+		"listKeys": {".spec.containers": "name"},
+		"missingKeys": 1,
+		"subset": false,
+		"positionalLists": true,
+		"scalarLists": "set"
+	}`)
+	want := compare.Options{
+		Ignore:          []string{".a", ".b"},
+		Only:            []string{".spec"},
+		ListKeys:        map[string]string{".spec.containers": "name"},
+		MissingKeys:     true,
+		PositionalLists: true,
+		ScalarLists:     "set",
+	}
+	if got := wasmOptions(val); !reflect.DeepEqual(got, want) {
+		t.Errorf("wasmOptions() = %#v, want %#v", got, want)
+	}
+	if got := wasmOptions(js.Undefined()); !reflect.DeepEqual(got, compare.Options{}) {
+		t.Errorf("wasmOptions(undefined) = %#v, want no options", got)
+	}
+}
@@ -0,0 +1,54 @@
+//go:build !js && !wasip1
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// wasiRunner runs a wasip1 module with the WASI support of Node.js, passing
+// its standard input and output through
+const wasiRunner = `
+const { readFileSync } = require("node:fs");
+const { WASI } = require("node:wasi");
+const wasi = new WASI({ version: "preview1", args: ["yamldiff-wasm"], returnOnExit: true });
+WebAssembly.instantiate(readFileSync(process.argv[2]), wasi.getImportObject())
+  .then(({ instance }) => { process.exitCode = wasi.start(instance); });
+`
+
+func TestWASIBuild(t *testing.T) {
+	if testing.Short() {
+		t.Skip("building the wasip1 module is slow")
+	}
+	if _, err := exec.LookPath("node"); err != nil {
+		t.Skip("node is not installed")
+	}
+
+	dir := t.TempDir()
+	module := filepath.Join(dir, "yamldiff.wasm")
+	build := exec.Command("go", "build", "-o", module, ".")
+	build.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm")
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("go build: %v\n%s", err, out)
+	}
+	runner := filepath.Join(dir, "run.js")
+	if err := os.WriteFile(runner, []byte(wasiRunner), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command("node", "--no-warnings", runner, module)
+	cmd.Stdin = strings.NewReader(`{"left": "a: 1\nb: 1\n", "right": "a: 2\n", "options": {"ignore": [".a"], "missingKeys": true}}`)
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("node: %v", err)
+	}
+	want := `{"changes":[{"path":".b","kind":"removed","left":1,` +
+		`"leftRange":{"line":2,"column":1,"endLine":2,"endColumn":5}}]}`
+	if got := strings.TrimSpace(string(out)); got != want {
+		t.Errorf("output =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// ANSI escape sequences of the colors used in the output
+const (
+	colorReset  = "\x1b[0m"
+	colorBold   = "\x1b[1m"
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+)
+
+// useColor reports whether output is colored in the given --color mode. In
+// auto mode output is colored when writing to a terminal, unless NO_COLOR is
+// set or the terminal is dumb.
+func useColor(mode string) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto", "":
+		return os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb" && term.IsTerminal(int(os.Stdout.Fd())), nil
+	}
+	return false, fmt.Errorf("invalid --color mode %q, expected auto, always or never", mode)
+}
+
+// paint wraps text in a color when the printer uses colors
+func (p *printer) paint(color, text string) string {
+	if !p.color || color == "" || text == "" {
+		return text
+	}
+	return color + text + colorReset
+}
+
+// visibleWidth returns the number of runes of a line that are not part of an
+// escape sequence
+func visibleWidth(line string) int {
+	width, escape := 0, false
+	for _, r := range line {
+		switch {
+		case r == '\x1b':
+			escape = true
+		case escape:
+			escape = r != 'm'
+		default:
+			width++
+		}
+	}
+	return width
+}
+
+// cutVisible returns the leading runes of a line up to the given visible
+// width, keeping escape sequences intact and closing an open color
+func cutVisible(line string, width int) string {
+	var b strings.Builder
+	visible, escape, colored := 0, false, false
+	for _, r := range line {
+		switch {
+		case r == '\x1b':
+			escape, colored = true, true
+		case escape:
+			escape = r != 'm'
+		default:
+			if visible == width {
+				if colored {
+					b.WriteString(colorReset)
+				}
+				return b.String()
+			}
+			visible++
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
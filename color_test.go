@@ -0,0 +1,44 @@
+package main
+
+import "testing"
+
+func TestVisibleWidth(t *testing.T) {
+	tests := []struct {
+		line string
+		want int
+	}{
+		{"", 0},
+		{"abc", 3},
+		{"äöü→", 4},
+		{colorRed + "abc" + colorReset, 3},
+		{"a" + colorBold + "b" + colorReset + colorGreen + "c", 3},
+	}
+
+	for _, tt := range tests {
+		if got := visibleWidth(tt.line); got != tt.want {
+			t.Errorf("visibleWidth(%q) = %d, want %d", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		line  string
+		width int
+		want  string
+	}{
+		{"abcdef", 0, "abcdef"},
+		{"abcdef", 6, "abcdef"},
+		{"abcdef", 4, "abc…"},
+		{"äöüäöü", 3, "äö…"},
+		{colorRed + "abcdef" + colorReset, 4, colorRed + "abc" + colorReset + "…"},
+		{"ab" + colorRed + "cdef" + colorReset, 3, "ab" + colorRed + colorReset + "…"},
+	}
+
+	for _, tt := range tests {
+		p := &printer{width: tt.width}
+		if got := p.truncate(tt.line); got != tt.want {
+			t.Errorf("truncate(%q) to %d = %q, want %q", tt.line, tt.width, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+// Package compare compares YAML documents semantically: maps are compared by
+// key regardless of their order, values by their type and value rather than
+// how they are written. It is the engine of the yamldiff command line tool,
+// its gRPC service and its WebAssembly builds.
+//
+// Documents compares two documents given as bytes:
+//
+//	changes, err := compare.Documents(left, right, compare.Options{MissingKeys: true})
+//
+// Changes encode to JSON with their values converted to JSON types, so the
+// result can be passed on as it is.
+package compare
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Kind describes how a value differs between the two documents
+type Kind string
+
+const (
+	Modified Kind = "modified"
+	Added    Kind = "added"
+	Removed  Kind = "removed"
+)
+
+// Change is a single difference found between the two documents
+type Change struct {
+	Path  string
+	Kind  Kind
+	Left  interface{}
+	Right interface{}
+	// LeftRange and RightRange locate the values in the documents, when known
+	LeftRange  *Range
+	RightRange *Range
+}
+
+// MarshalJSON encodes a change with its values converted to JSON types
+func (ch Change) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Path       string      `json:"path"`
+		Kind       Kind        `json:"kind"`
+		Left       interface{} `json:"left,omitempty"`
+		Right      interface{} `json:"right,omitempty"`
+		LeftRange  *Range      `json:"leftRange,omitempty"`
+		RightRange *Range      `json:"rightRange,omitempty"`
+	}{ch.Path, ch.Kind, JSONValue(ch.Left), JSONValue(ch.Right), ch.LeftRange, ch.RightRange})
+}
+
+// Options controls which differences are reported
+type Options struct {
+	// Ignore holds path patterns whose differences are not reported
+	Ignore []string `json:"ignore,omitempty"`
+	// Only restricts the comparison to the paths matching one of its
+	// patterns when it is not empty
+	Only []string `json:"only,omitempty"`
+	// MissingKeys reports keys present in only one of the documents instead of skipping them
+	MissingKeys bool `json:"missingKeys,omitempty"`
+	// Subset requires everything in the first document to be contained in the
+	// second one, which may have additional keys and list elements
+	Subset bool `json:"subset,omitempty"`
+	// ListKeys maps path patterns of lists to the field identifying their
+	// elements, so elements are matched by key instead of position
+	ListKeys map[string]string `json:"listKeys,omitempty"`
+	// PositionalLists compares lists without a list key by position instead
+	// of inferring a field identifying their elements
+	PositionalLists bool `json:"positionalLists,omitempty"`
+	// ScalarLists compares lists of scalars by position, or as a set or
+	// multiset regardless of the order of their elements
+	ScalarLists string `json:"scalarLists,omitempty"`
+}
+
+// Comparison collects the changes found while comparing documents
+type Comparison struct {
+	// Options are completed by the directives of the compared documents
+	Options Options
+	Changes []Change
+	// Tracef explains the decisions of the comparison when it is set
+	Tracef func(format string, args ...interface{})
+	// Notef reports choices made without being configured, like inferred
+	// list keys, when it is set
+	Notef func(format string, args ...interface{})
+	// Warnf reports suspicious content, like repeated elements of a set,
+	// when it is set
+	Warnf func(format string, args ...interface{})
+}
+
+// trace explains a decision of the comparison
+func (c *Comparison) trace(format string, args ...interface{}) {
+	if c.Tracef != nil {
+		c.Tracef(format, args...)
+	}
+}
+
+// warn reports suspicious content of the documents
+func (c *Comparison) warn(format string, args ...interface{}) {
+	if c.Warnf != nil {
+		c.Warnf(format, args...)
+	}
+}
+
+// note reports a choice made without being configured
+func (c *Comparison) note(format string, args ...interface{}) {
+	if c.Notef != nil {
+		c.Notef(format, args...)
+	}
+}
+
+// Compare compares two documents with the rules declared in their comments
+// and adds the changes, located in both documents, to c.Changes. It returns
+// the differing values of the first document, nested like in it. In subset
+// mode the first document may contain matchers.
+func (c *Comparison) Compare(doc1, doc2 *Document) map[interface{}]interface{} {
+	found := len(c.Changes)
+	diffMap := make(map[interface{}]interface{})
+	c.applyDirectives(doc1, doc2)
+	c.compareMaps(doc1.Content, doc2.Content, "", diffMap)
+	annotateRanges(c.Changes[found:], doc1, doc2)
+	return diffMap
+}
+
+// compareMaps recursively compares two maps and records a change when a difference is found.
+// It skips differences where a key is missing in one of the maps unless MissingKeys is set.
+// Differing values from the first map are collected into diffMap.
+func (c *Comparison) compareMaps(map1, map2 map[interface{}]interface{}, path string, diffMap map[interface{}]interface{}) {
+	for _, key := range SortedKeys(map1) {
+		val1 := map1[key]
+		newPath := JoinPath(path, key)
+		if c.ignored(newPath) {
+			continue
+		}
+
+		val2, ok := map2[key]
+		if !ok {
+			// Skip cases where the key is missing in the second map
+			if c.Options.MissingKeys || c.Options.Subset {
+				if c.record(Change{Path: newPath, Kind: Removed, Left: val1}) {
+					diffMap[key] = val1
+				}
+			} else {
+				c.trace("%s: only in the first file, keys missing on one side are not reported", newPath)
+			}
+			continue
+		}
+
+		switch val1Typed := val1.(type) {
+		case map[interface{}]interface{}:
+			if nestedMap2, ok := val2.(map[interface{}]interface{}); ok {
+				subDiffMap := make(map[interface{}]interface{})
+				c.compareMaps(val1Typed, nestedMap2, newPath, subDiffMap)
+				if len(subDiffMap) > 0 {
+					diffMap[key] = subDiffMap
+				}
+			} else {
+				if !reflect.DeepEqual(val1, val2) {
+					c.record(Change{Path: newPath, Kind: Modified, Left: val1, Right: val2})
+				}
+				diffMap[key] = val1
+			}
+		default:
+			list1, ok1 := val1.([]interface{})
+			list2, ok2 := val2.([]interface{})
+			field, pattern, setMode := c.listStrategy(newPath, val1, val2)
+			if ok1 && ok2 && pattern == "" && !c.Options.Subset {
+				if field != "" {
+					c.note("%s: matching list elements by %s, inferred as it identifies every element", newPath, field)
+				} else if setMode == "" {
+					c.trace("%s: comparing lists by position, as no list key selects them", newPath)
+				}
+			}
+			switch {
+			case setMode != "":
+				c.trace("%s: comparing the elements as a %s", newPath, setMode)
+				if c.compareScalarLists(list1, list2, newPath, setMode == "multiset") {
+					diffMap[key] = val1
+				}
+			case field != "" && ok1 && ok2:
+				if pattern != "" {
+					c.trace("%s: matching list elements by %s (list key %s)", newPath, field, pattern)
+				}
+				if c.compareKeyedLists(list1, list2, newPath, field) {
+					diffMap[key] = val1
+				}
+			case c.Options.Subset && ok1 && ok2:
+				c.trace("%s: matching list elements in any order (subset mode)", newPath)
+				if !c.compareSubsetLists(list1, list2, newPath) {
+					diffMap[key] = val1
+				}
+			case c.Options.Subset && !subsetMatches(val1, val2) || !c.Options.Subset && !reflect.DeepEqual(val1, val2):
+				if c.record(Change{Path: newPath, Kind: Modified, Left: val1, Right: val2}) {
+					diffMap[key] = val1
+				}
+			default:
+				if m, ok := val1.(*Matcher); ok {
+					c.trace("%s: %s accepted by %s", newPath, InlineValue(val2), m)
+				} else {
+					c.trace("%s: equal", newPath)
+				}
+			}
+		}
+	}
+
+	// Also check if there are keys in map2 that are missing in map1
+	for _, key := range SortedKeys(map2) {
+		if _, ok := map1[key]; ok {
+			continue
+		}
+
+		// Skip cases where the key is missing in the first map
+		newPath := JoinPath(path, key)
+		if c.Options.Subset {
+			c.trace("%s: only in the second file, allowed in subset mode", newPath)
+		} else if !c.Options.MissingKeys {
+			c.trace("%s: only in the second file, keys missing on one side are not reported", newPath)
+		} else if !c.ignored(newPath) {
+			c.record(Change{Path: newPath, Kind: Added, Right: map2[key]})
+		}
+	}
+}
+
+// Documents compares two YAML documents and returns their changes with the
+// positions of the values. In subset mode the first document may contain
+// matchers.
+func Documents(data1, data2 []byte, options Options) ([]Change, error) {
+	if err := CheckScalarListMode(options.ScalarLists); err != nil {
+		return nil, err
+	}
+	doc1, err := Parse(data1, options.Subset)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing first document: %v", err)
+	}
+	doc2, err := Parse(data2, false)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing second document: %v", err)
+	}
+
+	c := &Comparison{Options: options}
+	c.Compare(doc1, doc2)
+	return c.Changes, nil
+}
+
+// ignored reports whether a path matches one of the ignore patterns, or
+// cannot lead to a path selected by the only patterns
+func (c *Comparison) ignored(path string) bool {
+	for _, pattern := range c.Options.Ignore {
+		if PathMatches(pattern, path) {
+			c.trace("%s: ignored by pattern %s", path, pattern)
+			return true
+		}
+	}
+	for _, pattern := range c.Options.Only {
+		if pathLeadsTo(pattern, path) {
+			return false
+		}
+	}
+	if len(c.Options.Only) > 0 {
+		c.trace("%s: not selected by the only patterns %v", path, c.Options.Only)
+		return true
+	}
+	return false
+}
+
+// record adds a change unless only patterns are given and none of them
+// selects its path. It reports whether the change was added.
+func (c *Comparison) record(ch Change) bool {
+	selected := len(c.Options.Only) == 0
+	for _, pattern := range c.Options.Only {
+		selected = selected || PathMatches(pattern, ch.Path)
+	}
+	if !selected {
+		c.trace("%s: difference not reported, as it is not selected by the only patterns %v", ch.Path, c.Options.Only)
+		return false
+	}
+	c.trace("%s: reported as %s", ch.Path, ch.Kind)
+	c.Changes = append(c.Changes, ch)
+	return true
+}
+
+// SortedKeys returns the keys of a map sorted by their string form, so
+// output does not depend on map iteration order
+func SortedKeys(m map[interface{}]interface{}) []interface{} {
+	keys := make([]interface{}, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+	})
+	return keys
+}
@@ -0,0 +1,149 @@
+package compare
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestRenderValue(t *testing.T) {
+	tests := []struct {
+		name string
+		val  interface{}
+		want string
+	}{
+		{"int", 1, "1"},
+		{"float written like an int", 1.0, "1.0"},
+		{"float", 1.5, "1.5"},
+		{"large float", 1e21, "1e+21"},
+		{"string like a bool", "true", `"true"`},
+		{"string like a YAML 1.1 bool", "yes", `"yes"`},
+		{"string like a number", "1", `"1"`},
+		{"null", nil, "null"},
+		{"list of floats", []interface{}{1.0, 2}, "- 1.0\n- 2"},
+		{"map with sorted keys", map[interface{}]interface{}{"b": 1, "a": []interface{}{"x"}}, "a:\n  - x\nb: 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RenderValue(tt.val); got != tt.want {
+				t.Errorf("RenderValue(%#v) = %q, want %q", tt.val, got, tt.want)
+			}
+		})
+	}
+}
+
+// changePaths returns the paths of changes
+func changePaths(changes []Change) []string {
+	var paths []string
+	for _, ch := range changes {
+		paths = append(paths, ch.Path)
+	}
+	return paths
+}
+
+func TestCompareDocumentsOnly(t *testing.T) {
+	left := "spec:\n  replicas: 1\n  template:\n    image: a\n    containers:\n      - name: web\n        image: a\n"
+	right := "spec:\n  replicas: 2\n  template:\n    image: b\n    containers:\n      - name: web\n        image: b\n"
+
+	tests := []struct {
+		only []string
+		want []string
+	}{
+		{nil, []string{".spec.replicas", ".spec.template.containers[name=web].image", ".spec.template.image"}},
+		{[]string{".spec.**.image"}, []string{".spec.template.containers[name=web].image", ".spec.template.image"}},
+		{[]string{".spec.template.containers"}, []string{".spec.template.containers[name=web].image"}},
+		{[]string{".spec.replicas", ".spec.template.image"}, []string{".spec.replicas", ".spec.template.image"}},
+		{[]string{".status"}, nil},
+	}
+
+	for _, tt := range tests {
+		changes, err := Documents([]byte(left), []byte(right), Options{Only: tt.only})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := changePaths(changes); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("only %v: paths = %v, want %v", tt.only, got, tt.want)
+		}
+	}
+}
+
+func TestComparisonTrace(t *testing.T) {
+	left := "metadata:\n  uid: 1\nextra: 1\nenabled: yes\ncontainers:\n  - name: web\n    image: a\nports: [80]\n"
+	right := "metadata:\n  uid: 2\nenabled: true\ncontainers:\n  - name: web\n    image: b\nports: [80, 443]\n"
+	doc1, err := Parse([]byte(left), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc2, err := Parse([]byte(right), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var traces, notes []string
+	c := &Comparison{
+		Options: Options{Ignore: []string{".metadata"}, PositionalLists: true},
+		Tracef:  func(format string, args ...interface{}) { traces = append(traces, fmt.Sprintf(format, args...)) },
+		Notef:   func(format string, args ...interface{}) { notes = append(notes, fmt.Sprintf(format, args...)) },
+	}
+	c.compareMaps(doc1.Content, doc2.Content, "", make(map[interface{}]interface{}))
+
+	want := []string{
+		".containers: comparing lists by position, as no list key selects them",
+		".containers: reported as modified",
+		".enabled: equal",
+		".extra: only in the first file, keys missing on one side are not reported",
+		".metadata: ignored by pattern .metadata",
+		".ports: comparing lists by position, as no list key selects them",
+		".ports: reported as modified",
+	}
+	if !reflect.DeepEqual(traces, want) {
+		t.Errorf("traces =\n%q\nwant\n%q", traces, want)
+	}
+	if len(notes) != 0 {
+		t.Errorf("notes = %q, want none with positional lists", notes)
+	}
+
+	// Without positional lists the key of the containers is inferred
+	notes = nil
+	c.Options.PositionalLists, c.Changes = false, nil
+	c.compareMaps(doc1.Content, doc2.Content, "", make(map[interface{}]interface{}))
+	if want := []string{".containers: matching list elements by name, inferred as it identifies every element"}; !reflect.DeepEqual(notes, want) {
+		t.Errorf("notes = %q, want %q", notes, want)
+	}
+}
+
+func TestNormalizationTrace(t *testing.T) {
+	root, err := Decode([]byte(`enabled: yes
+port: 010
+name: web
+version: !!str 1
+empty:
+yes: 1
+base: &base {x: 1}
+copy: *base
+other:
+  <<: *base
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var traces []string
+	tracef := func(format string, args ...interface{}) { traces = append(traces, fmt.Sprintf(format, args...)) }
+	if _, err := Normalize(root, false, tracef); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		".enabled: yes read as true",
+		".port: 010 read as 8",
+		`.version: 1 read as "1" by its !!str tag`,
+		".empty: an empty value read as null",
+		".true: key yes read as true",
+		".copy: alias *base read as the value anchored on line 7",
+		".other: keys merged by << on line 10",
+	}
+	if !reflect.DeepEqual(traces, want) {
+		t.Errorf("traces =\n%q\nwant\n%q", traces, want)
+	}
+}
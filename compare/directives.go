@@ -0,0 +1,218 @@
+package compare
+
+import (
+	"fmt"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// directivePrefix starts a comment directive such as # yamldiff:ignore
+const directivePrefix = "yamldiff:"
+
+// Directive is a comparison rule declared in a comment of a file
+type Directive struct {
+	// Name is ignore, ignore-next or list-key
+	Name  string
+	Value string
+	Line  int
+}
+
+// commentDirectives returns the directives found in the comments of a node
+func commentDirectives(comments ...string) []string {
+	var directives []string
+	for _, comment := range comments {
+		for _, line := range strings.Split(comment, "\n") {
+			line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#"))
+			if strings.HasPrefix(line, directivePrefix) {
+				directives = append(directives, strings.TrimPrefix(line, directivePrefix))
+			}
+		}
+	}
+	return directives
+}
+
+// parseDirective splits a directive such as list-key=name found on a line
+func parseDirective(text string, line int) Directive {
+	name, value, _ := strings.Cut(text, "=")
+	return Directive{strings.TrimSpace(name), strings.TrimSpace(value), line}
+}
+
+// NodeDirectives returns the directives applying to a map entry or list
+// element: directives in the comment on its line and in the comment directly
+// above it
+func NodeDirectives(key, value *yamlv3.Node) []Directive {
+	var comments []string
+	at := value.Line
+	if key != nil {
+		comments = append(comments, key.LineComment)
+		at = key.Line
+	}
+	comments = append(comments, value.LineComment)
+	if key != nil {
+		comments = append(comments, key.HeadComment)
+	}
+	comments = append(comments, value.HeadComment)
+
+	var directives []Directive
+	for _, text := range commentDirectives(comments...) {
+		directives = append(directives, parseDirective(text, at))
+	}
+	return directives
+}
+
+// StrayDirectives returns the directives of a document that apply to no
+// value: those in comments below a value and those at the top or bottom of
+// the document that are separated from its keys by a blank line. The line of
+// a directive below a value is the line of that value, 0 for directives of
+// the document.
+func StrayDirectives(doc *yamlv3.Node) []Directive {
+	var stray []Directive
+	for _, text := range commentDirectives(doc.HeadComment, doc.FootComment) {
+		stray = append(stray, parseDirective(text, 0))
+	}
+
+	var walk func(node *yamlv3.Node)
+	walk = func(node *yamlv3.Node) {
+		for _, text := range commentDirectives(node.FootComment) {
+			stray = append(stray, parseDirective(text, node.Line))
+		}
+		for _, child := range node.Content {
+			walk(child)
+		}
+	}
+	for _, child := range doc.Content {
+		walk(child)
+	}
+	return stray
+}
+
+// CheckDirective fails for a directive that is unknown or lacks its value
+func CheckDirective(d Directive) error {
+	switch d.Name {
+	case "ignore", "ignore-next":
+		if d.Value != "" {
+			return fmt.Errorf("directive %s takes no value", d.Name)
+		}
+	case "list-key":
+		if d.Value == "" {
+			return fmt.Errorf("directive list-key needs a field, e.g. list-key=name")
+		}
+	default:
+		return fmt.Errorf("unknown directive %s", d.Name)
+	}
+	return nil
+}
+
+// applyDirectives adds the ignore rules and list keys declared in comments of
+// the two documents to the options of the comparison. List keys are
+// collected first, so ignored list elements are named like the comparison
+// names them, by their configured or inferred key or by their value.
+func (c *Comparison) applyDirectives(doc1, doc2 *Document) {
+	listKeys := make(map[string]string, len(c.Options.ListKeys))
+	for pattern, field := range c.Options.ListKeys {
+		listKeys[pattern] = field
+	}
+	ignore := append([]string{}, c.Options.Ignore...)
+	c.Options.ListKeys = listKeys
+
+	// The values of both documents at a path are followed along the walk of
+	// either one, as list keys are inferred from both lists
+	var contents [2]interface{}
+	for side, doc := range []*Document{doc1, doc2} {
+		if doc != nil {
+			contents[side] = doc.Content
+		}
+	}
+
+	for _, collectIgnores := range []bool{false, true} {
+		for side, doc := range []*Document{doc1, doc2} {
+			if doc == nil || doc.Root == nil || len(doc.Root.Content) == 0 {
+				continue
+			}
+			if collectIgnores {
+				for _, d := range StrayDirectives(doc.Root) {
+					c.trace("directive %s below line %d applies to no value", d.Name, d.Line)
+				}
+			}
+
+			var walk func(path string, key, node *yamlv3.Node, vals [2]interface{})
+			walk = func(path string, key, node *yamlv3.Node, vals [2]interface{}) {
+				if path != "" {
+					for _, d := range NodeDirectives(key, node) {
+						switch {
+						case d.Name == "list-key" && d.Value != "" && !collectIgnores:
+							c.trace("%s: list key %s declared on line %d", path, d.Value, d.Line)
+							listKeys[literalPattern(path)] = d.Value
+						case (d.Name == "ignore" || d.Name == "ignore-next") && collectIgnores:
+							c.trace("%s: ignored by the comment on line %d", path, d.Line)
+							ignore = append(ignore, literalPattern(path))
+						case d.Name != "list-key" && d.Name != "ignore" && d.Name != "ignore-next" && collectIgnores:
+							c.trace("%s: unknown directive %s on line %d", path, d.Name, d.Line)
+						}
+					}
+				}
+
+				switch node.Kind {
+				case yamlv3.MappingNode:
+					for i := 0; i+1 < len(node.Content); i += 2 {
+						k, err := ScalarValue(node.Content[i])
+						if err != nil || node.Content[i].Tag == "!!merge" {
+							continue
+						}
+						var children [2]interface{}
+						for j, val := range vals {
+							if m, ok := val.(map[interface{}]interface{}); ok {
+								children[j] = m[k]
+							}
+						}
+						walk(JoinPath(path, k), node.Content[i], node.Content[i+1], children)
+					}
+				case yamlv3.SequenceNode:
+					field, _, setMode := c.listStrategy(path, vals[0], vals[1])
+					var lists [2][]interface{}
+					for j, val := range vals {
+						lists[j], _ = val.([]interface{})
+					}
+					occurrences := make(map[interface{}]int)
+					for i, item := range node.Content {
+						itemPath, children := IndexPath(path, i), [2]interface{}{}
+						for j, list := range lists {
+							if i < len(list) {
+								children[j] = list[i]
+							}
+						}
+						if i < len(lists[side]) {
+							switch element := lists[side][i]; {
+							case setMode == "multiset":
+								occurrences[element]++
+								itemPath = occurrencePath(path, element, occurrences[element])
+							case setMode == "set":
+								itemPath = KeyPath(path, "", element)
+							case field != "":
+								if value, ok := scalarField(element, field); ok {
+									itemPath, children = KeyPath(path, field, value), [2]interface{}{}
+									for j, list := range lists {
+										for _, other := range list {
+											if v, ok := scalarField(other, field); ok && v == value {
+												children[j] = other
+												break
+											}
+										}
+									}
+								}
+							}
+						}
+						walk(itemPath, nil, item, children)
+					}
+				}
+			}
+			walk("", nil, doc.Root.Content[0], contents)
+		}
+	}
+
+	c.Options.Ignore = ignore
+	if len(listKeys) == 0 {
+		c.Options.ListKeys = nil
+	}
+}
@@ -0,0 +1,142 @@
+package compare
+
+import (
+	"reflect"
+	"testing"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+func TestDirectives(t *testing.T) {
+	tests := []struct {
+		name        string
+		left, right string
+		options     Options
+		want        []string
+	}{
+		{
+			name:  "ignore on a line",
+			left:  "a: 1 # yamldiff:ignore\nb: 1\n",
+			right: "a: 2\nb: 2\n",
+			want:  []string{".b"},
+		},
+		{
+			name:  "ignore-next above a key in the second file",
+			left:  "a: 1\nb: 1\n",
+			right: "# yamldiff:ignore-next\na: 2\nb: 2\n",
+			want:  []string{".b"},
+		},
+		{
+			name:  "ignore in a list with an inferred key",
+			left:  "containers:\n  - name: web\n    image: a # yamldiff:ignore\n    port: 80\n  - name: db\n    image: a\n",
+			right: "containers:\n  - name: db\n    image: b\n  - name: web\n    image: b\n    port: 81\n",
+			want:  []string{".containers[name=web].port", ".containers[name=db].image"},
+		},
+		{
+			name:  "ignore in a list keyed by a list-key directive",
+			left:  "containers: # yamldiff:list-key=id\n  - id: 1\n    image: a # yamldiff:ignore\n  - id: 2\n    image: a\n",
+			right: "containers:\n  - id: 2\n    image: b\n  - id: 1\n    image: b\n",
+			want:  []string{".containers[id=2].image"},
+		},
+		{
+			name:  "ignore in the comment above a key",
+			left:  "# yamldiff:ignore\na: 1\nb: 1\n",
+			right: "a: 2\nb: 2\n",
+			want:  []string{".b"},
+		},
+		{
+			name:  "ignore a key with glob characters",
+			left:  "\"*\": 1 # yamldiff:ignore\n\"**\": 1 # yamldiff:ignore\nab: 1\n",
+			right: "\"*\": 2\n\"**\": 2\nab: 2\n",
+			want:  []string{".ab"},
+		},
+		{
+			name:  "ignore a key with glob characters below a list",
+			left:  "\"a?\": # yamldiff:ignore\n  x: 1\nab:\n  x: 1\n\"[a]\": 1 # yamldiff:ignore\na: 1\n",
+			right: "\"a?\":\n  x: 2\nab:\n  x: 2\n\"[a]\": 2\na: 2\n",
+			want:  []string{".a", ".ab.x"},
+		},
+		{
+			name:    "list key of a list under a key with glob characters",
+			left:    "\"*\": # yamldiff:list-key=id\n  - id: 1\n  - id: 2\nother:\n  - id: 1\n  - id: 2\n",
+			right:   "\"*\":\n  - id: 2\n  - id: 1\nother:\n  - id: 2\n  - id: 1\n",
+			options: Options{PositionalLists: true},
+			want:    []string{".other"},
+		},
+		{
+			name:    "ignore an element of a scalar set",
+			left:    "hosts:\n  - a\n  - b # yamldiff:ignore\n",
+			right:   "hosts:\n  - c\n",
+			options: Options{ScalarLists: "set"},
+			want:    []string{".hosts[=a]", ".hosts[=c]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := Documents([]byte(tt.left), []byte(tt.right), tt.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := changePaths(changes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("paths = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNodeDirectives(t *testing.T) {
+	var root yamlv3.Node
+	data := "# yamldiff:ignore-next\n# yamldiff:ignore\na: 1\nb: 1 # yamldiff:list-key = name\nc: 1 # a comment\n"
+	if err := yamlv3.Unmarshal([]byte(data), &root); err != nil {
+		t.Fatal(err)
+	}
+	m := root.Content[0]
+
+	want := [][]Directive{
+		{{"ignore-next", "", 3}, {"ignore", "", 3}},
+		{{"list-key", "name", 4}},
+		nil,
+	}
+	for i := 0; i < 3; i++ {
+		if got := NodeDirectives(m.Content[2*i], m.Content[2*i+1]); !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("directives of %s = %+v, want %+v", m.Content[2*i].Value, got, want[i])
+		}
+	}
+}
+
+func TestStrayDirectives(t *testing.T) {
+	var root yamlv3.Node
+	data := "# yamldiff:ignore\n\na:\n  b: 1\n  # yamldiff:ignore-next\nc: 1 # yamldiff:ignore\n"
+	if err := yamlv3.Unmarshal([]byte(data), &root); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Directive{{"ignore", "", 0}, {"ignore-next", "", 4}}
+	if got := StrayDirectives(&root); !reflect.DeepEqual(got, want) {
+		t.Errorf("StrayDirectives() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCheckDirective(t *testing.T) {
+	tests := []struct {
+		d    Directive
+		want string
+	}{
+		{Directive{Name: "ignore"}, ""},
+		{Directive{Name: "list-key", Value: "name"}, ""},
+		{Directive{Name: "ignore-next", Value: "2"}, "directive ignore-next takes no value"},
+		{Directive{Name: "list-key"}, "directive list-key needs a field, e.g. list-key=name"},
+		{Directive{Name: "skip"}, "unknown directive skip"},
+	}
+
+	for _, tt := range tests {
+		got := ""
+		if err := CheckDirective(tt.d); err != nil {
+			got = err.Error()
+		}
+		if got != tt.want {
+			t.Errorf("CheckDirective(%+v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package compare
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Document is a parsed YAML file together with its node tree, which keeps
+// the position of every value
+type Document struct {
+	Content map[interface{}]interface{}
+	Root    *yamlv3.Node
+}
+
+// Parse parses a YAML document. The document is read as a node tree so
+// tags and positions are available, while plain scalars are resolved with the
+// YAML 1.1 rules of the map unmarshal used before, e.g. yes is a boolean and
+// timestamps stay strings. With matchers set, values with a matcher tag are
+// turned into matchers.
+func Parse(data []byte, matchers bool) (*Document, error) {
+	root, err := Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	return Normalize(root, matchers, nil)
+}
+
+// Decode parses a YAML document into its node tree
+func Decode(data []byte) (*yamlv3.Node, error) {
+	var root yamlv3.Node
+	if err := yamlv3.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	return &root, nil
+}
+
+// Normalize resolves the node tree of a document into its values. The
+// values read differently than they are written, which makes values written
+// in different ways equal, are reported to tracef when it is set.
+func Normalize(root *yamlv3.Node, matchers bool, tracef func(format string, args ...interface{})) (*Document, error) {
+	if len(root.Content) == 0 {
+		return &Document{Root: root}, nil
+	}
+	if tracef != nil {
+		traceNormalization(root.Content[0], "", tracef)
+	}
+
+	val, err := NodeValue(root.Content[0], matchers)
+	if err != nil {
+		return nil, err
+	}
+	content, ok := val.(map[interface{}]interface{})
+	if !ok && val != nil {
+		return nil, fmt.Errorf("line %d: document is not a map", root.Content[0].Line)
+	}
+	return &Document{Content: content, Root: root}, nil
+}
+
+// traceNormalization reports the values of a node tree that are read
+// differently than they are written: plain scalars resolved by the YAML 1.1
+// rules, like yes read as true or 010 as 8, scalars with an explicit tag,
+// aliases and merge keys
+func traceNormalization(node *yamlv3.Node, path string, tracef func(format string, args ...interface{})) {
+	at := path
+	if at == "" {
+		at = "."
+	}
+
+	switch node.Kind {
+	case yamlv3.AliasNode:
+		tracef("%s: alias *%s read as the value anchored on line %d", at, node.Value, node.Alias.Line)
+	case yamlv3.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Tag == "!!merge" {
+				tracef("%s: keys merged by << on line %d", at, key.Line)
+				continue
+			}
+			k, err := ScalarValue(key)
+			if err != nil {
+				continue
+			}
+			if written, read := key.Value, RenderValue(k); key.Style == 0 && k != key.Value && written != read {
+				tracef("%s: key %s read as %s", JoinPath(path, k), written, read)
+			}
+			traceNormalization(value, JoinPath(path, k), tracef)
+		}
+	case yamlv3.SequenceNode:
+		for i, item := range node.Content {
+			traceNormalization(item, IndexPath(path, i), tracef)
+		}
+	case yamlv3.ScalarNode:
+		val, err := ScalarValue(node)
+		tagged := node.Style&yamlv3.TaggedStyle != 0 && strings.HasPrefix(node.Tag, "!!")
+		if s, ok := val.(string); err != nil || ok && s == node.Value && !tagged || !tagged && node.Style != 0 {
+			return
+		}
+		written, read := node.Value, RenderValue(val)
+		if written == "" {
+			written = "an empty value"
+		}
+		switch {
+		case tagged:
+			tracef("%s: %s read as %s by its %s tag", at, written, read, node.Tag)
+		case written != read:
+			tracef("%s: %s read as %s", at, written, read)
+		}
+	}
+}
+
+// DecodeDocuments parses every document of a YAML stream as a node tree.
+// Streams holding only comments yield no document.
+func DecodeDocuments(data []byte) ([]*yamlv3.Node, error) {
+	var docs []*yamlv3.Node
+	dec := yamlv3.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc yamlv3.Node
+		if err := dec.Decode(&doc); err == io.EOF {
+			return docs, nil
+		} else if err != nil {
+			return nil, err
+		}
+		docs = append(docs, &doc)
+	}
+}
+
+// NodeValue converts a YAML node into maps, lists and scalars
+func NodeValue(node *yamlv3.Node, matchers bool) (interface{}, error) {
+	if matchers {
+		if m, ok, err := newMatcher(node); ok || err != nil {
+			return m, err
+		}
+	}
+
+	switch node.Kind {
+	case yamlv3.AliasNode:
+		return NodeValue(node.Alias, matchers)
+	case yamlv3.MappingNode:
+		m := make(map[interface{}]interface{}, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyNode, ValueNode := node.Content[i], node.Content[i+1]
+			val, err := NodeValue(ValueNode, matchers)
+			if err != nil {
+				return nil, err
+			}
+
+			// Merge keys copy the keys of the referenced maps
+			if keyNode.Tag == "!!merge" {
+				mergeValues := []interface{}{val}
+				if list, ok := val.([]interface{}); ok {
+					mergeValues = list
+				}
+				for _, merged := range mergeValues {
+					if mergedMap, ok := merged.(map[interface{}]interface{}); ok {
+						for k, v := range mergedMap {
+							if _, exists := m[k]; !exists {
+								m[k] = v
+							}
+						}
+					}
+				}
+				continue
+			}
+
+			key, err := NodeValue(keyNode, false)
+			if err != nil {
+				return nil, err
+			}
+			if IsCollection(key) {
+				return nil, fmt.Errorf("line %d: complex keys are not supported", keyNode.Line)
+			}
+			m[key] = val
+		}
+		return m, nil
+	case yamlv3.SequenceNode:
+		list := make([]interface{}, len(node.Content))
+		for i, item := range node.Content {
+			val, err := NodeValue(item, matchers)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = val
+		}
+		return list, nil
+	}
+
+	return ScalarValue(node)
+}
+
+// ScalarValue resolves a scalar node. Explicitly tagged scalars are decoded
+// by their tag, quoted and block scalars are strings and plain scalars are
+// resolved with YAML 1.1 rules.
+func ScalarValue(node *yamlv3.Node) (interface{}, error) {
+	if node.Style&yamlv3.TaggedStyle != 0 && strings.HasPrefix(node.Tag, "!!") && node.Tag != "!!timestamp" {
+		var val interface{}
+		if err := node.Decode(&val); err != nil {
+			return nil, err
+		}
+		return val, nil
+	}
+
+	if node.Style&(yamlv3.DoubleQuotedStyle|yamlv3.SingleQuotedStyle|yamlv3.LiteralStyle|yamlv3.FoldedStyle) != 0 {
+		return node.Value, nil
+	}
+
+	// Custom tags and timestamps are kept as their plain value
+	var val interface{}
+	if err := yaml.Unmarshal([]byte(node.Value), &val); err != nil || IsCollection(val) {
+		return node.Value, nil
+	}
+	return val, nil
+}
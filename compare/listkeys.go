@@ -0,0 +1,233 @@
+package compare
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// KeyPath appends the element of a keyed list to a key path, e.g.
+// .spec.containers[name=web]
+func KeyPath(path, field string, value interface{}) string {
+	name := fmt.Sprint(value)
+	if name == "" || strings.ContainsAny(name, "[]\"=# \t\n") {
+		name = strconv.Quote(name)
+	}
+	return fmt.Sprintf("%s[%s=%s]", path, field, name)
+}
+
+// ParseListKeys parses list keys given as pattern=field
+func ParseListKeys(specs []string) (map[string]string, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+
+	listKeys := make(map[string]string, len(specs))
+	for _, spec := range specs {
+		i := strings.LastIndex(spec, "=")
+		if i <= 0 || i == len(spec)-1 {
+			return nil, fmt.Errorf("invalid list key %q, expected pattern=field", spec)
+		}
+		listKeys[spec[:i]] = spec[i+1:]
+	}
+	return listKeys, nil
+}
+
+// listKey returns the field identifying the elements of the list at path and
+// the pattern selecting it, or empty strings when its elements are compared
+// by position
+func (c *Comparison) listKey(path string) (field, pattern string) {
+	patterns := make([]string, 0, len(c.Options.ListKeys))
+	for pattern := range c.Options.ListKeys {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	for _, pattern := range patterns {
+		if matchSegments(SplitPath(pattern), SplitPath(path), false, matchPathSegment) {
+			return c.Options.ListKeys[pattern], pattern
+		}
+	}
+	return "", ""
+}
+
+// listStrategy decides how the values at path are compared when one of them
+// is a list. It returns the field identifying list elements and the list key
+// pattern it comes from, or the inferred field when no pattern selects the
+// lists, or set or multiset for lists of scalars compared that way. All are
+// empty when the lists are compared by position. The comparison and the
+// comment directives both use it, so they name elements alike.
+func (c *Comparison) listStrategy(path string, val1, val2 interface{}) (field, pattern, setMode string) {
+	if field, pattern = c.listKey(path); field != "" {
+		return field, pattern, ""
+	}
+
+	list1, ok1 := val1.([]interface{})
+	list2, ok2 := val2.([]interface{})
+	if !ok1 || !ok2 || c.Options.Subset {
+		return "", "", ""
+	}
+	if !c.Options.PositionalLists {
+		if field = inferListKey(list1, list2); field != "" {
+			return field, "", ""
+		}
+	}
+	return "", "", c.scalarListMode(list1, list2)
+}
+
+// keyedElements indexes the elements of a list by the value of their key
+// field. It fails when an element is not a map, lacks the field or shares
+// its value with another element.
+func keyedElements(list []interface{}, field string) (map[interface{}]int, bool) {
+	index := make(map[interface{}]int, len(list))
+	for i, item := range list {
+		m, ok := item.(map[interface{}]interface{})
+		if !ok {
+			return nil, false
+		}
+		value, ok := m[field]
+		if !ok || IsCollection(value) {
+			return nil, false
+		}
+		if _, dup := index[value]; dup {
+			return nil, false
+		}
+		index[value] = i
+	}
+	return index, true
+}
+
+// compareKeyedLists compares two lists whose elements are identified by a key
+// field instead of their position, so reordering elements is not a change.
+// Elements present in only one list are reported as removed or added, in
+// subset mode only those missing in the second list. Lists that cannot be
+// keyed are compared as a whole, in subset mode by matching their elements
+// in any order. It reports whether a difference was found.
+func (c *Comparison) compareKeyedLists(list1, list2 []interface{}, path, field string) bool {
+	index1, ok1 := keyedElements(list1, field)
+	index2, ok2 := keyedElements(list2, field)
+	if !ok1 || !ok2 {
+		c.trace("%s: elements cannot be identified by %s, as one is not a map, lacks it or repeats its value; comparing the lists as a whole", path, field)
+		if c.Options.Subset {
+			return !c.compareSubsetLists(list1, list2, path)
+		}
+		if reflect.DeepEqual(list1, list2) {
+			return false
+		}
+		return c.record(Change{Path: path, Kind: Modified, Left: list1, Right: list2})
+	}
+
+	found := len(c.Changes)
+	for _, item := range list1 {
+		m1 := item.(map[interface{}]interface{})
+		value := m1[field]
+		elementPath := KeyPath(path, field, value)
+		if c.ignored(elementPath) {
+			continue
+		}
+
+		j, ok := index2[value]
+		if !ok {
+			c.record(Change{Path: elementPath, Kind: Removed, Left: item})
+			continue
+		}
+		c.compareMaps(m1, list2[j].(map[interface{}]interface{}), elementPath, make(map[interface{}]interface{}))
+	}
+
+	for _, item := range list2 {
+		value := item.(map[interface{}]interface{})[field]
+		elementPath := KeyPath(path, field, value)
+		if _, ok := index1[value]; ok || c.ignored(elementPath) {
+			continue
+		}
+		if c.Options.Subset {
+			c.trace("%s: only in the second file, allowed in subset mode", elementPath)
+		} else {
+			c.record(Change{Path: elementPath, Kind: Added, Right: item})
+		}
+	}
+	return len(c.Changes) > found
+}
+
+// preferredListKeys are the fields tried first when inferring the key of a
+// list, in this order
+var preferredListKeys = []string{"name", "id", "key"}
+
+// scalarField returns the value of a field of a list element when the element
+// is a map and the value is a scalar
+func scalarField(item interface{}, field string) (interface{}, bool) {
+	m, ok := item.(map[interface{}]interface{})
+	if !ok {
+		return nil, false
+	}
+	switch value := m[field].(type) {
+	case nil, map[interface{}]interface{}, []interface{}:
+		return nil, false
+	default:
+		return value, true
+	}
+}
+
+// inferListKey returns a field identifying the elements of two lists when no
+// list key is configured, or an empty string. Every element of both lists
+// must be a map with a scalar value for the field that is unique within its
+// list. name, id and key are preferred; any other field must also have a
+// value found in both lists, so that it pairs elements, and the one pairing
+// the most elements wins.
+func inferListKey(list1, list2 []interface{}) string {
+	if len(list1) == 0 || len(list2) == 0 {
+		return ""
+	}
+
+	// shared returns how many values of the field are found in both lists,
+	// or -1 when the field cannot identify the elements
+	shared := func(field string) int {
+		var values [2]map[interface{}]bool
+		for side, list := range [][]interface{}{list1, list2} {
+			values[side] = make(map[interface{}]bool, len(list))
+			for _, item := range list {
+				value, ok := scalarField(item, field)
+				if !ok || values[side][value] {
+					return -1
+				}
+				values[side][value] = true
+			}
+		}
+
+		n := 0
+		for value := range values[0] {
+			if values[1][value] {
+				n++
+			}
+		}
+		return n
+	}
+
+	for _, field := range preferredListKeys {
+		if shared(field) >= 0 {
+			return field
+		}
+	}
+
+	first, ok := list1[0].(map[interface{}]interface{})
+	if !ok {
+		return ""
+	}
+	var fields []string
+	for key := range first {
+		if field, ok := key.(string); ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	best, bestShared := "", 0
+	for _, field := range fields {
+		if n := shared(field); n > bestShared {
+			best, bestShared = field, n
+		}
+	}
+	return best
+}
@@ -0,0 +1,113 @@
+package compare
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKeyPath(t *testing.T) {
+	tests := []struct {
+		field string
+		value interface{}
+		want  string
+	}{
+		{"name", "web", ".list[name=web]"},
+		{"id", 1, ".list[id=1]"},
+		{"name", "a b", `.list[name="a b"]`},
+		{"name", "x=y", `.list[name="x=y"]`},
+		{"name", "", `.list[name=""]`},
+	}
+
+	for _, tt := range tests {
+		if got := KeyPath(".list", tt.field, tt.value); got != tt.want {
+			t.Errorf("KeyPath(%q, %#v) = %q, want %q", tt.field, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseListKeys(t *testing.T) {
+	got, err := ParseListKeys([]string{".spec.containers=name", ".env[*]=key=id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{".spec.containers": "name", ".env[*]=key": "id"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseListKeys() = %v, want %v", got, want)
+	}
+
+	for _, spec := range []string{"name", "=name", ".list="} {
+		if _, err := ParseListKeys([]string{spec}); err == nil {
+			t.Errorf("ParseListKeys(%q) succeeded, want an error", spec)
+		}
+	}
+}
+
+func TestCompareKeyedLists(t *testing.T) {
+	left := `containers:
+  - name: web
+    image: web:1
+  - name: cache
+    image: redis
+  - name: old
+    image: old
+`
+	right := `containers:
+  - name: cache
+    image: redis
+  - name: web
+    image: web:2
+  - name: new
+    image: new
+`
+	options := Options{ListKeys: map[string]string{".containers": "name"}}
+	changes, err := Documents([]byte(left), []byte(right), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{".containers[name=web].image", ".containers[name=old]", ".containers[name=new]"}
+	if got := changePaths(changes); !reflect.DeepEqual(got, want) {
+		t.Errorf("paths = %v, want %v", got, want)
+	}
+
+	// Elements repeating their key are compared as a whole list
+	right = "containers:\n  - name: web\n  - name: web\n"
+	changes, err = Documents([]byte(left), []byte(right), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := changePaths(changes); !reflect.DeepEqual(got, []string{".containers"}) {
+		t.Errorf("paths with repeated keys = %v, want [.containers]", got)
+	}
+}
+
+func TestInferListKey(t *testing.T) {
+	m := func(pairs ...interface{}) map[interface{}]interface{} {
+		item := make(map[interface{}]interface{})
+		for i := 0; i+1 < len(pairs); i += 2 {
+			item[pairs[i]] = pairs[i+1]
+		}
+		return item
+	}
+
+	tests := []struct {
+		name         string
+		list1, list2 []interface{}
+		want         string
+	}{
+		{"name preferred", []interface{}{m("name", "a", "port", 1)}, []interface{}{m("name", "b", "port", 1)}, "name"},
+		{"id when names repeat", []interface{}{m("name", "a", "id", 1), m("name", "a", "id", 2)}, []interface{}{m("name", "a", "id", 2)}, "id"},
+		{"field pairing the most elements", []interface{}{m("host", "a", "port", 1), m("host", "b", "port", 2)}, []interface{}{m("host", "a", "port", 3), m("host", "b", "port", 1)}, "host"},
+		{"no shared value", []interface{}{m("host", "a")}, []interface{}{m("host", "b")}, ""},
+		{"element lacking the field", []interface{}{m("name", "a"), m("image", "b")}, []interface{}{m("name", "a")}, ""},
+		{"scalars", []interface{}{1, 2}, []interface{}{2}, ""},
+		{"empty list", nil, []interface{}{m("name", "a")}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inferListKey(tt.list1, tt.list2); got != tt.want {
+				t.Errorf("inferListKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
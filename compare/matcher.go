@@ -0,0 +1,115 @@
+package compare
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Matcher is a placeholder in a reference file that accepts a range of values
+// instead of a single one, written with a custom tag such as !regex '^v\d+'
+type Matcher struct {
+	// Tag is the tag without its leading "!": any, regex, type or range
+	Tag      string
+	Pattern  *regexp.Regexp
+	TypeName string
+	Min, Max float64
+	// Source is the matcher as written in the reference file
+	Source string
+}
+
+// matcherTypes lists the type names accepted by !type
+var matcherTypes = []string{"string", "int", "float", "number", "bool", "null", "map", "list"}
+
+// String returns the matcher as written in the reference file
+func (m *Matcher) String() string {
+	return m.Source
+}
+
+// Matches reports whether a value is accepted by the matcher
+func (m *Matcher) Matches(val interface{}) bool {
+	switch m.Tag {
+	case "any":
+		return true
+	case "regex":
+		s, ok := val.(string)
+		if !ok && val != nil && !IsCollection(val) {
+			s, ok = RenderScalar(val), true
+		}
+		return ok && m.Pattern.MatchString(s)
+	case "type":
+		return valueType(val) == m.TypeName ||
+			m.TypeName == "number" && (valueType(val) == "int" || valueType(val) == "float")
+	case "range":
+		n, ok := ToFloat(val)
+		return ok && n >= m.Min && n <= m.Max
+	}
+	return false
+}
+
+// valueType returns the type name of a YAML value as used by !type
+func valueType(val interface{}) string {
+	switch val.(type) {
+	case string:
+		return "string"
+	case int, int64, uint64:
+		return "int"
+	case float64:
+		return "float"
+	case bool:
+		return "bool"
+	case nil:
+		return "null"
+	case map[interface{}]interface{}:
+		return "map"
+	case []interface{}:
+		return "list"
+	}
+	return fmt.Sprintf("%T", val)
+}
+
+// newMatcher creates the matcher for a node with a matcher tag. ok is false
+// for nodes without one.
+func newMatcher(node *yamlv3.Node) (m *Matcher, ok bool, err error) {
+	tag := strings.TrimPrefix(node.Tag, "!")
+	if !strings.HasPrefix(node.Tag, "!") || strings.HasPrefix(node.Tag, "!!") {
+		return nil, false, nil
+	}
+
+	m = &Matcher{Tag: tag}
+	switch tag {
+	case "any":
+		m.Source = "!any"
+	case "regex":
+		m.Pattern, err = regexp.Compile(node.Value)
+		m.Source = "!regex '" + strings.ReplaceAll(node.Value, "'", "''") + "'"
+	case "type":
+		m.TypeName = node.Value
+		m.Source = "!type " + node.Value
+		err = fmt.Errorf("unknown type %q, expected one of %s", node.Value, strings.Join(matcherTypes, ", "))
+		for _, name := range matcherTypes {
+			if name == node.Value {
+				err = nil
+			}
+		}
+	case "range":
+		var bounds []float64
+		if err = node.Decode(&bounds); err == nil && len(bounds) != 2 {
+			err = fmt.Errorf("!range needs a minimum and a maximum")
+		}
+		if err == nil {
+			m.Min, m.Max = bounds[0], bounds[1]
+			m.Source = fmt.Sprintf("!range [%v, %v]", m.Min, m.Max)
+		}
+	default:
+		// Other custom tags are kept as plain values
+		return nil, false, nil
+	}
+
+	if err != nil {
+		return nil, false, fmt.Errorf("line %d: %v", node.Line, err)
+	}
+	return m, true, nil
+}
@@ -0,0 +1,72 @@
+package compare
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatcherMatches(t *testing.T) {
+	tests := []struct {
+		source string
+		val    interface{}
+		want   bool
+	}{
+		{"!any x", nil, true},
+		{"!any x", map[interface{}]interface{}{"a": 1}, true},
+		{`!regex '^v\d+$'`, "v12", true},
+		{`!regex '^v\d+$'`, "12", false},
+		{`!regex '^\d+$'`, 12, true},
+		{`!regex '.'`, []interface{}{1}, false},
+		{"!type string", "1", true},
+		{"!type string", 1, false},
+		{"!type number", 1.5, true},
+		{"!type number", 1, true},
+		{"!type list", []interface{}{}, true},
+		{"!range [1, 5]", 5, true},
+		{"!range [1, 5]", 5.5, false},
+		{"!range [1, 5]", "3", false},
+	}
+
+	for _, tt := range tests {
+		doc, err := Parse([]byte("v: "+tt.source), true)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.source, err)
+		}
+		m, ok := doc.Content["v"].(*Matcher)
+		if !ok {
+			t.Fatalf("%s: got %#v, want a matcher", tt.source, doc.Content["v"])
+		}
+		if got := m.Matches(tt.val); got != tt.want {
+			t.Errorf("%s matches %#v = %v, want %v", tt.source, tt.val, got, tt.want)
+		}
+	}
+}
+
+func TestNewMatcherErrors(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"!regex '['", "line 1: error parsing regexp"},
+		{"!type text", `line 1: unknown type "text"`},
+		{"!range [1]", "line 1: !range needs a minimum and a maximum"},
+		{"!range [a, b]", "line 1:"},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse([]byte("v: "+tt.source), true)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error = %v, want one containing %q", tt.source, err, tt.want)
+		}
+	}
+}
+
+func TestMatcherTagsWithoutMatchers(t *testing.T) {
+	doc, err := Parse([]byte("a: !regex '^v'\nb: !custom value\n"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Content["a"] != "^v" || doc.Content["b"] != "value" {
+		t.Errorf("content = %#v, want the plain values", doc.Content)
+	}
+}
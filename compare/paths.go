@@ -0,0 +1,205 @@
+package compare
+
+import (
+	"fmt"
+	pathpkg "path"
+	"strconv"
+	"strings"
+)
+
+// JoinPath appends a map key to a key path. Keys that would make the path
+// ambiguous, such as keys containing dots, are quoted.
+func JoinPath(path string, key interface{}) string {
+	name := fmt.Sprint(key)
+	if name == "" || strings.ContainsAny(name, ".[]\" \t\n") {
+		name = strconv.Quote(name)
+	}
+	return path + "." + name
+}
+
+// IndexPath appends a list index to a key path
+func IndexPath(path string, index int) string {
+	return fmt.Sprintf("%s[%d]", path, index)
+}
+
+// SplitPath splits a key path such as .spec."app.kubernetes.io/name"[0] into
+// its segments. Keys are returned unquoted and list indexes keep their
+// brackets. A leading dot is optional.
+func SplitPath(path string) []string {
+	var segments []string
+	for i := 0; i < len(path); {
+		switch path[i] {
+		case '.':
+			i++
+		case '[':
+			// Find the closing bracket outside of a quoted key value
+			end, quoted := i+1, false
+			for end < len(path) && (quoted || path[end] != ']') {
+				switch {
+				case path[end] == '\\' && quoted:
+					end++
+				case path[end] == '"':
+					quoted = !quoted
+				}
+				end++
+			}
+			if end >= len(path) {
+				end = len(path) - 1
+			}
+			segments = append(segments, path[i:end+1])
+			i = end + 1
+		case '"':
+			// Find the closing quote, skipping escaped characters
+			end := i + 1
+			for end < len(path) && path[end] != '"' {
+				if path[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(path) {
+				end = len(path) - 1
+			}
+			key, err := strconv.Unquote(path[i : end+1])
+			if err != nil {
+				key = path[i+1 : end+1]
+			}
+			segments = append(segments, key)
+			i = end + 1
+		default:
+			end := strings.IndexAny(path[i:], ".[")
+			if end < 0 {
+				end = len(path) - i
+			}
+			segments = append(segments, path[i:i+end])
+			i += end
+		}
+	}
+	return segments
+}
+
+// globEscaper escapes the characters of a key that pathMatches reads as glob
+// characters
+var globEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`)
+
+// literalPattern returns the pattern matching exactly the given path, whose
+// keys may contain glob characters, e.g. .\* for the key *
+func literalPattern(path string) string {
+	pattern := ""
+	for _, segment := range SplitPath(path) {
+		if strings.HasPrefix(segment, "[") {
+			pattern += segment
+		} else {
+			pattern = JoinPath(pattern, globEscaper.Replace(segment))
+		}
+	}
+	return pattern
+}
+
+// PathMatches reports whether a path is selected by a pattern. Patterns are
+// key paths in which a key may contain glob characters, [*] matches any list
+// index and ** matches any number of segments. A pattern also matches every
+// path below the one it selects.
+func PathMatches(pattern, path string) bool {
+	return matchSegments(SplitPath(pattern), SplitPath(path), true, matchPathSegment)
+}
+
+// pathLeadsTo reports whether a path is selected by a pattern or may contain
+// paths selected by it, e.g. .spec leads to .spec.containers[*].image
+func pathLeadsTo(pattern, path string) bool {
+	patternSegments, segments := SplitPath(pattern), SplitPath(path)
+	for i, segment := range segments {
+		if i == len(patternSegments) || patternSegments[i] == "**" {
+			return true
+		}
+		if !matchPathSegment(patternSegments[i], segment) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchPathSegment matches a single key or list index against a pattern segment
+func matchPathSegment(pattern, segment string) bool {
+	if strings.HasPrefix(pattern, "[") {
+		if pattern == "[*]" && strings.HasPrefix(segment, "[") || pattern == segment {
+			return true
+		}
+		// An element of a scalar list selects all its occurrences
+		element, _ := splitOccurrence(segment)
+		return pattern == element
+	}
+	if strings.HasPrefix(segment, "[") {
+		return false
+	}
+	if ok, err := pathpkg.Match(pattern, segment); err == nil {
+		return ok
+	}
+	return pattern == segment
+}
+
+// MatchGlob reports whether a slash-separated file name matches a glob
+// pattern, where ** matches any number of directories
+func MatchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"), false, func(p, s string) bool {
+		ok, err := pathpkg.Match(p, s)
+		return err == nil && ok
+	})
+}
+
+// matchSegments matches segments against pattern segments, where a "**"
+// pattern segment matches any number of segments. With prefix set, the
+// pattern only has to match the leading segments.
+func matchSegments(pattern, segments []string, prefix bool, match func(p, s string) bool) bool {
+	if len(pattern) == 0 {
+		return prefix || len(segments) == 0
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:], prefix, match) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(segments) == 0 || !match(pattern[0], segments[0]) {
+		return false
+	}
+	return matchSegments(pattern[1:], segments[1:], prefix, match)
+}
+
+// Selection is a value found at a path
+type Selection struct {
+	Path  string
+	Value interface{}
+}
+
+// SelectValues returns every value in a YAML tree whose path is matched
+// exactly by the pattern, in document order with map keys sorted
+func SelectValues(val interface{}, pattern string) []Selection {
+	var selected []Selection
+	patternSegments := SplitPath(pattern)
+
+	var walk func(path string, val interface{})
+	walk = func(path string, val interface{}) {
+		if matchSegments(patternSegments, SplitPath(path), false, matchPathSegment) {
+			selected = append(selected, Selection{path, val})
+		}
+
+		switch v := val.(type) {
+		case map[interface{}]interface{}:
+			for _, key := range SortedKeys(v) {
+				walk(JoinPath(path, key), v[key])
+			}
+		case []interface{}:
+			for i, item := range v {
+				walk(IndexPath(path, i), item)
+			}
+		}
+	}
+
+	walk("", val)
+	return selected
+}
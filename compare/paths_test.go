@@ -0,0 +1,116 @@
+package compare
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{".spec.replicas", []string{"spec", "replicas"}},
+		{"spec.containers[0].image", []string{"spec", "containers", "[0]", "image"}},
+		{`.metadata.labels."app.kubernetes.io/name"`, []string{"metadata", "labels", "app.kubernetes.io/name"}},
+		{`.list[name="a]b"].x`, []string{"list", `[name="a]b"]`, "x"}},
+		{".list[=a#2]", []string{"list", "[=a#2]"}},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		if got := SplitPath(tt.path); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestJoinPath(t *testing.T) {
+	if got, want := JoinPath(IndexPath(".labels", 1), "app.kubernetes.io/name"), `.labels[1]."app.kubernetes.io/name"`; got != want {
+		t.Errorf("JoinPath() = %q, want %q", got, want)
+	}
+}
+
+func TestPathMatches(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{".spec", ".spec.replicas", true},
+		{".spec.replicas", ".spec", false},
+		{".spec.*", ".spec.replicas", true},
+		{".spec.containers[*].image", ".spec.containers[name=web].image", true},
+		{".spec.containers[*].image", ".spec.containers.image", false},
+		{".**.image", ".spec.containers[0].image", true},
+		{".metadata.*.team", ".metadata.labels.team", true},
+		{".list[=a]", ".list[=a#2]", true},
+		{".list[=a#1]", ".list[=a#2]", false},
+	}
+
+	for _, tt := range tests {
+		if got := PathMatches(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("PathMatches(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestLiteralPattern(t *testing.T) {
+	tests := []struct {
+		path  string
+		other string
+	}{
+		{`.spec."*".image`, ".spec.replicas.image"},
+		{`."**"`, ".a.b"},
+		{`."a?"[name=web]`, ".ab[name=web]"},
+		{`."[ab]"`, ".a"},
+		{`."a\\*.b"`, `."a\\x.b"`},
+	}
+
+	for _, tt := range tests {
+		pattern := literalPattern(tt.path)
+		if !PathMatches(pattern, tt.path) {
+			t.Errorf("pattern %q does not match %q", pattern, tt.path)
+		}
+		if PathMatches(pattern, tt.other) {
+			t.Errorf("pattern %q matches %q", pattern, tt.other)
+		}
+	}
+}
+
+func TestPathLeadsTo(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{".spec.containers[*].image", ".spec", true},
+		{".spec.containers[*].image", ".spec.containers[0]", true},
+		{".spec.containers[*].image", ".status", false},
+		{".**.image", ".status.conditions", true},
+		{".spec", ".spec.replicas", true},
+	}
+
+	for _, tt := range tests {
+		if got := pathLeadsTo(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("pathLeadsTo(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"*.yaml", "a.yaml", true},
+		{"*.yaml", "k8s/a.yaml", false},
+		{"k8s/**/*.yaml", "k8s/a.yaml", true},
+		{"k8s/**/*.yaml", "k8s/prod/web/a.yaml", true},
+		{"**/values.yaml", "charts/web/values.yaml", true},
+	}
+
+	for _, tt := range tests {
+		if got := MatchGlob(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("MatchGlob(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
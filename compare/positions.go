@@ -0,0 +1,179 @@
+package compare
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Range is the position of a value in a file. Lines and columns start
+// at 1. Values of map keys start at their key. The end is the column after
+// the last character of the last scalar of the value.
+type Range struct {
+	Line      int `json:"line"`
+	Column    int `json:"column"`
+	EndLine   int `json:"endLine"`
+	EndColumn int `json:"endColumn"`
+}
+
+// String formats the start of a range as line:column
+func (r *Range) String() string {
+	return fmt.Sprintf("%d:%d", r.Line, r.Column)
+}
+
+// rangeOf returns the position of the value at a key path, or nil when the
+// Document has no such value
+func (d *Document) rangeOf(path string) *Range {
+	if d == nil || d.Root == nil || len(d.Root.Content) == 0 {
+		return nil
+	}
+
+	var key *yamlv3.Node
+	node := d.Root.Content[0]
+	for _, segment := range SplitPath(path) {
+		if node = ResolveAlias(node); node == nil {
+			return nil
+		}
+		if strings.HasPrefix(segment, "[") {
+			key, node = nil, ElementNode(node, segment)
+		} else {
+			key, node = mappingEntry(node, segment)
+		}
+		if node == nil {
+			return nil
+		}
+	}
+
+	start := node
+	if key != nil {
+		start = key
+	}
+	endLine, endColumn := nodeEnd(node)
+	return &Range{Line: start.Line, Column: start.Column, EndLine: endLine, EndColumn: endColumn}
+}
+
+// ResolveAlias returns the node an alias refers to
+func ResolveAlias(node *yamlv3.Node) *yamlv3.Node {
+	for node != nil && node.Kind == yamlv3.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
+// mappingEntry returns the key and value nodes of a map entry whose key is
+// named like the path segment. Later keys win over earlier ones and keys
+// merged with << come last, like when the document is loaded.
+func mappingEntry(node *yamlv3.Node, name string) (key, value *yamlv3.Node) {
+	if node.Kind != yamlv3.MappingNode {
+		return nil, nil
+	}
+
+	var merged []*yamlv3.Node
+	for i := len(node.Content) - 2; i >= 0; i -= 2 {
+		keyNode := node.Content[i]
+		if keyNode.Tag == "!!merge" {
+			merged = append(merged, node.Content[i+1])
+			continue
+		}
+		if k, err := ScalarValue(keyNode); err == nil && fmt.Sprint(k) == name {
+			return keyNode, node.Content[i+1]
+		}
+	}
+
+	for i := len(merged) - 1; i >= 0; i-- {
+		sources := []*yamlv3.Node{ResolveAlias(merged[i])}
+		if sources[0] != nil && sources[0].Kind == yamlv3.SequenceNode {
+			sources = sources[0].Content
+		}
+		for _, source := range sources {
+			if source = ResolveAlias(source); source != nil {
+				if key, value := mappingEntry(source, name); value != nil {
+					return key, value
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+// ElementNode returns the list element selected by a path segment, either
+// an index like [2], a key like [name=web] or a value like [=web], possibly
+// naming a later occurrence of the value like [=web#2]
+func ElementNode(node *yamlv3.Node, segment string) *yamlv3.Node {
+	if node.Kind != yamlv3.SequenceNode {
+		return nil
+	}
+
+	segment, occurrence := splitOccurrence(segment)
+	inner := segment[1 : len(segment)-1]
+	field, value, keyed := strings.Cut(inner, "=")
+	if !keyed {
+		index, err := strconv.Atoi(inner)
+		if err != nil || index < 0 || index >= len(node.Content) {
+			return nil
+		}
+		return node.Content[index]
+	}
+
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		value = unquoted
+	}
+	for _, item := range node.Content {
+		// Elements of scalar lists compared as sets are selected by value
+		if field == "" {
+			if item := ResolveAlias(item); item != nil && item.Kind == yamlv3.ScalarNode {
+				if val, err := ScalarValue(item); err == nil && fmt.Sprint(val) == value {
+					if occurrence == 1 {
+						return item
+					}
+					occurrence--
+				}
+			}
+			continue
+		}
+		if _, v := mappingEntry(ResolveAlias(item), field); v != nil {
+			if val, err := ScalarValue(v); err == nil && fmt.Sprint(val) == value {
+				return item
+			}
+		}
+	}
+	return nil
+}
+
+// nodeEnd returns the line and column after the last character of the last
+// scalar in a node
+func nodeEnd(node *yamlv3.Node) (line, column int) {
+	for len(node.Content) > 0 && node.Kind != yamlv3.AliasNode {
+		node = node.Content[len(node.Content)-1]
+	}
+
+	text := node.Value
+	switch {
+	case node.Kind == yamlv3.AliasNode:
+		text = "*" + text
+	case node.Style&yamlv3.DoubleQuotedStyle != 0:
+		text = strconv.Quote(text)
+	case node.Style&yamlv3.SingleQuotedStyle != 0:
+		text = "'" + strings.ReplaceAll(text, "'", "''") + "'"
+	case node.Style&(yamlv3.LiteralStyle|yamlv3.FoldedStyle) != 0:
+		// Block scalars start with their indicator and continue on the
+		// following lines
+		lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+		return node.Line + len(lines), node.Column + len(lines[len(lines)-1])
+	}
+	return node.Line, node.Column + len([]rune(text))
+}
+
+// annotateRanges sets the positions of the changed values in both documents
+func annotateRanges(changes []Change, left, right *Document) {
+	for i := range changes {
+		if changes[i].Kind != Added {
+			changes[i].LeftRange = left.rangeOf(changes[i].Path)
+		}
+		if changes[i].Kind != Removed {
+			changes[i].RightRange = right.rangeOf(changes[i].Path)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package compare
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRangeOf(t *testing.T) {
+	src := `name: web
+"a.b": 1
+spec:
+  ports: [80, 443]
+  containers:
+    - name: app
+      image: "nginx"
+base: &base
+  x: 1
+derived:
+  <<: *base
+  z: 2
+`
+	doc, err := Parse([]byte(src), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want *Range
+	}{
+		{".name", &Range{1, 1, 1, 10}},
+		{`."a.b"`, &Range{2, 1, 2, 9}},
+		{".spec.ports[1]", &Range{4, 15, 4, 18}},
+		{".spec.ports[=443]", &Range{4, 15, 4, 18}},
+		{".spec.containers[0].image", &Range{7, 7, 7, 21}},
+		{".spec.containers[name=app]", &Range{6, 7, 7, 21}},
+		{".derived.x", &Range{9, 3, 9, 7}},
+		{".derived.z", &Range{12, 3, 12, 7}},
+		{".spec.ports[2]", nil},
+		{".spec.containers[name=db]", nil},
+		{".missing", nil},
+		{".name.nested", nil},
+	}
+
+	for _, tt := range tests {
+		if got := doc.rangeOf(tt.path); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("rangeOf(%s) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestAnnotateRanges(t *testing.T) {
+	changes, err := Documents([]byte("a: 1\nb: 2\n"), []byte("c: 3\na: 10\n"), Options{MissingKeys: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Change{
+		{Path: ".a", Kind: Modified, Left: 1, Right: 10, LeftRange: &Range{1, 1, 1, 5}, RightRange: &Range{2, 1, 2, 6}},
+		{Path: ".b", Kind: Removed, Left: 2, LeftRange: &Range{2, 1, 2, 5}},
+		{Path: ".c", Kind: Added, Right: 3, RightRange: &Range{1, 1, 1, 5}},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("changes = %+v, want %+v", changes, want)
+	}
+}
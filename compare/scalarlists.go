@@ -0,0 +1,162 @@
+package compare
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ScalarListModes are the ways lists of scalars can be compared: by position,
+// as sets ignoring order and repetitions, or as multisets ignoring order only
+var ScalarListModes = []string{"position", "set", "multiset"}
+
+// CheckScalarListMode fails unless mode is one of scalarListModes or empty,
+// which compares lists of scalars by position
+func CheckScalarListMode(mode string) error {
+	if mode == "" {
+		return nil
+	}
+	for _, m := range ScalarListModes {
+		if mode == m {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid scalar list mode %q, expected one of %s", mode, strings.Join(ScalarListModes, ", "))
+}
+
+// scalarCounts counts the occurrences of the elements of a list and returns
+// them in the order they first appear. It fails when an element is a map or a
+// list.
+func scalarCounts(list []interface{}) (map[interface{}]int, []interface{}, bool) {
+	counts := make(map[interface{}]int, len(list))
+	var order []interface{}
+	for _, item := range list {
+		switch item.(type) {
+		case map[interface{}]interface{}, []interface{}, *Matcher:
+			return nil, nil, false
+		}
+		if counts[item] == 0 {
+			order = append(order, item)
+		}
+		counts[item]++
+	}
+	return counts, order, true
+}
+
+// scalarListMode returns set or multiset when the two lists hold only
+// scalars and are compared that way, or an empty string when they are
+// compared by position
+func (c *Comparison) scalarListMode(list1, list2 []interface{}) string {
+	if c.Options.ScalarLists != "set" && c.Options.ScalarLists != "multiset" {
+		return ""
+	}
+	if _, _, ok := scalarCounts(list1); !ok {
+		return ""
+	}
+	if _, _, ok := scalarCounts(list2); !ok {
+		return ""
+	}
+	return c.Options.ScalarLists
+}
+
+// compareScalarLists compares two lists of scalars regardless of the order
+// of their elements. Elements are reported as removed or added at paths like
+// .hosts[=a.example.com]. As a set, only whether an element is present
+// matters and repeated elements are reported as warnings; as a multiset every
+// occurrence counts, and later occurrences of an element are reported at
+// paths like .hosts[=a.example.com#2]. It reports whether a difference was
+// found.
+func (c *Comparison) compareScalarLists(list1, list2 []interface{}, path string, multiset bool) bool {
+	counts1, order1, _ := scalarCounts(list1)
+	counts2, order2, _ := scalarCounts(list2)
+	if !multiset {
+		sides := []struct {
+			name   string
+			counts map[interface{}]int
+			order  []interface{}
+		}{{"first", counts1, order1}, {"second", counts2, order2}}
+		for _, side := range sides {
+			for _, item := range side.order {
+				if side.counts[item] > 1 {
+					c.warn("%s: %s appears %d times in the %s file", path, InlineValue(item), side.counts[item], side.name)
+				}
+			}
+		}
+	}
+
+	found := len(c.Changes)
+	for _, item := range order1 {
+		c.scalarElementChanges(path, item, counts1[item], counts2[item], multiset, Removed)
+	}
+	for _, item := range order2 {
+		c.scalarElementChanges(path, item, counts2[item], counts1[item], multiset, Added)
+	}
+	return len(c.Changes) > found
+}
+
+// scalarElementChanges records the occurrences of an element found n times
+// in one list that the other list, holding it other times, lacks. They are
+// removed when the element is from the first list and added otherwise. As a
+// set, an element is only missing when the other list has none.
+func (c *Comparison) scalarElementChanges(path string, item interface{}, n, other int, multiset bool, kind Kind) {
+	first := other + 1
+	if !multiset {
+		if other > 0 {
+			return
+		}
+		first, n = 1, 1
+	}
+
+	for k := first; k <= n; k++ {
+		elementPath := occurrencePath(path, item, k)
+		if c.ignored(elementPath) {
+			continue
+		}
+		ch := Change{Path: elementPath, Kind: kind}
+		if kind == Removed {
+			ch.Left = item
+		} else {
+			ch.Right = item
+		}
+		c.record(ch)
+	}
+}
+
+// occurrencePath appends an occurrence of an element of a scalar list to a
+// key path: .hosts[=a] for the first one and .hosts[=a#2] for the second
+func occurrencePath(path string, value interface{}, n int) string {
+	elementPath := KeyPath(path, "", value)
+	if n > 1 {
+		elementPath = fmt.Sprintf("%s#%d]", elementPath[:len(elementPath)-1], n)
+	}
+	return elementPath
+}
+
+// splitOccurrence splits a path segment like [=a#2] into the segment naming
+// the element, [=a], and the number of its occurrence, which is 1 when the
+// segment has none
+func splitOccurrence(segment string) (string, int) {
+	if !strings.HasPrefix(segment, "[=") || !strings.HasSuffix(segment, "]") {
+		return segment, 1
+	}
+
+	// Values containing # are quoted, so a # after the value starts the
+	// occurrence
+	value := segment[2 : len(segment)-1]
+	end := strings.LastIndex(value, "#")
+	if strings.HasPrefix(value, "\"") {
+		quoted, err := strconv.QuotedPrefix(value)
+		if err != nil {
+			return segment, 1
+		}
+		end = len(quoted)
+	}
+	if end < 0 || end >= len(value) || value[end] != '#' {
+		return segment, 1
+	}
+	n, err := strconv.Atoi(value[end+1:])
+	if err != nil || n < 2 {
+		return segment, 1
+	}
+	return "[=" + value[:end] + "]", n
+}
@@ -0,0 +1,121 @@
+package compare
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompareScalarLists(t *testing.T) {
+	tests := []struct {
+		name        string
+		left, right string
+		options     Options
+		want        []Change
+	}{
+		{
+			name:    "reordered set",
+			left:    "hosts: [a, b]\n",
+			right:   "hosts: [b, a]\n",
+			options: Options{ScalarLists: "set"},
+		},
+		{
+			name:    "set ignores repetitions",
+			left:    "hosts: [a, a, b]\n",
+			right:   "hosts: [b, c]\n",
+			options: Options{ScalarLists: "set"},
+			want: []Change{
+				{Path: ".hosts[=a]", Kind: Removed, Left: "a", LeftRange: &Range{1, 9, 1, 10}},
+				{Path: ".hosts[=c]", Kind: Added, Right: "c", RightRange: &Range{1, 12, 1, 13}},
+			},
+		},
+		{
+			name:    "multiset occurrences have their own paths and ranges",
+			left:    "hosts: [a, b, a, a]\n",
+			right:   "hosts: [a, b, b]\n",
+			options: Options{ScalarLists: "multiset"},
+			want: []Change{
+				{Path: ".hosts[=a#2]", Kind: Removed, Left: "a", LeftRange: &Range{1, 15, 1, 16}},
+				{Path: ".hosts[=a#3]", Kind: Removed, Left: "a", LeftRange: &Range{1, 18, 1, 19}},
+				{Path: ".hosts[=b#2]", Kind: Added, Right: "b", RightRange: &Range{1, 15, 1, 16}},
+			},
+		},
+		{
+			name:    "ignoring an element ignores all its occurrences",
+			left:    "hosts: [a, a, b]\n",
+			right:   "hosts: [c]\n",
+			options: Options{ScalarLists: "multiset", Ignore: []string{".hosts[=a]"}},
+			want: []Change{
+				{Path: ".hosts[=b]", Kind: Removed, Left: "b", LeftRange: &Range{1, 15, 1, 16}},
+				{Path: ".hosts[=c]", Kind: Added, Right: "c", RightRange: &Range{1, 9, 1, 10}},
+			},
+		},
+		{
+			name:    "ignore comment on one occurrence",
+			left:    "hosts:\n  - a\n  - a # yamldiff:ignore\n",
+			right:   "hosts: []\n",
+			options: Options{ScalarLists: "multiset"},
+			want: []Change{
+				{Path: ".hosts[=a]", Kind: Removed, Left: "a", LeftRange: &Range{2, 5, 2, 6}},
+			},
+		},
+		{
+			name:    "values with # are quoted",
+			left:    "colors: ['#fff', '#fff']\n",
+			right:   "colors: []\n",
+			options: Options{ScalarLists: "multiset"},
+			want: []Change{
+				{Path: `.colors[="#fff"]`, Kind: Removed, Left: "#fff", LeftRange: &Range{1, 10, 1, 16}},
+				{Path: `.colors[="#fff"#2]`, Kind: Removed, Left: "#fff", LeftRange: &Range{1, 18, 1, 24}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := Documents([]byte(tt.left), []byte(tt.right), tt.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(changes, tt.want) {
+				t.Errorf("changes = %+v, want %+v", changes, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitOccurrence(t *testing.T) {
+	tests := []struct {
+		segment string
+		want    string
+		n       int
+	}{
+		{"[=a]", "[=a]", 1},
+		{"[=a#2]", "[=a]", 2},
+		{"[=a#12]", "[=a]", 12},
+		{`[="#fff"]`, `[="#fff"]`, 1},
+		{`[="#fff"#3]`, `[="#fff"]`, 3},
+		{"[=a#1]", "[=a#1]", 1},
+		{"[=a#x]", "[=a#x]", 1},
+		{"[name=a#2]", "[name=a#2]", 1},
+		{"[2]", "[2]", 1},
+	}
+
+	for _, tt := range tests {
+		if got, n := splitOccurrence(tt.segment); got != tt.want || n != tt.n {
+			t.Errorf("splitOccurrence(%s) = %s, %d, want %s, %d", tt.segment, got, n, tt.want, tt.n)
+		}
+	}
+}
+
+func TestCompareDocumentsScalarListMode(t *testing.T) {
+	for _, mode := range append([]string{""}, ScalarListModes...) {
+		if _, err := Documents([]byte("a: 1\n"), []byte("a: 1\n"), Options{ScalarLists: mode}); err != nil {
+			t.Errorf("mode %q: unexpected error: %v", mode, err)
+		}
+	}
+	_, err := Documents([]byte("a: 1\n"), []byte("a: 1\n"), Options{ScalarLists: "bag"})
+	if err == nil || !strings.Contains(err.Error(), `"bag"`) {
+		t.Errorf("error = %v, want one naming the invalid mode", err)
+	}
+}
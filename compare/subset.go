@@ -0,0 +1,104 @@
+package compare
+
+import "reflect"
+
+// subsetMatches reports whether a pattern value is contained in an actual
+// value: maps must contain every key of the pattern with a matching value,
+// lists must contain a distinct matching element for every pattern element
+// in any order, matchers must accept the value and scalars must be equal
+func subsetMatches(pattern, actual interface{}) bool {
+	switch p := pattern.(type) {
+	case *Matcher:
+		return p.Matches(actual)
+	case map[interface{}]interface{}:
+		a, ok := actual.(map[interface{}]interface{})
+		if !ok {
+			return false
+		}
+		for key, val := range p {
+			actualVal, ok := a[key]
+			if !ok || !subsetMatches(val, actualVal) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		if !ok {
+			return false
+		}
+		assignment := matchListElements(p, a)
+		for _, index := range assignment {
+			if index < 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(pattern, actual)
+}
+
+// matchListElements assigns every pattern element a distinct actual element
+// it is contained in, maximizing the number of assigned elements. The result
+// holds the index of the assigned actual element per pattern element, or -1
+// when none is left.
+func matchListElements(pattern, actual []interface{}) []int {
+	// candidates[i] lists the actual elements pattern element i is contained in
+	candidates := make([][]int, len(pattern))
+	for i, p := range pattern {
+		for j, a := range actual {
+			if subsetMatches(p, a) {
+				candidates[i] = append(candidates[i], j)
+			}
+		}
+	}
+
+	// Bipartite matching with augmenting paths, so an earlier element taking
+	// the only candidate of a later one is moved to another candidate
+	owner := make([]int, len(actual))
+	for j := range owner {
+		owner[j] = -1
+	}
+	var augment func(i int, visited []bool) bool
+	augment = func(i int, visited []bool) bool {
+		for _, j := range candidates[i] {
+			if visited[j] {
+				continue
+			}
+			visited[j] = true
+			if owner[j] < 0 || augment(owner[j], visited) {
+				owner[j] = i
+				return true
+			}
+		}
+		return false
+	}
+	for i := range pattern {
+		augment(i, make([]bool, len(actual)))
+	}
+
+	assignment := make([]int, len(pattern))
+	for i := range assignment {
+		assignment[i] = -1
+	}
+	for j, i := range owner {
+		if i >= 0 {
+			assignment[i] = j
+		}
+	}
+	return assignment
+}
+
+// compareSubsetLists records a change for every element of the pattern list
+// that is not contained in a distinct element of the actual list. It reports
+// whether the pattern list is contained.
+func (c *Comparison) compareSubsetLists(pattern, actual []interface{}, path string) bool {
+	contained := true
+	for i, index := range matchListElements(pattern, actual) {
+		elementPath := IndexPath(path, i)
+		if index < 0 && !c.ignored(elementPath) && c.record(Change{Path: elementPath, Kind: Removed, Left: pattern[i]}) {
+			contained = false
+		}
+	}
+	return contained
+}
@@ -0,0 +1,121 @@
+package compare
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSubsetMatches(t *testing.T) {
+	tests := []struct {
+		name            string
+		pattern, actual interface{}
+		want            bool
+	}{
+		{"equal scalars", 1, 1, true},
+		{"different scalars", 1, 2, false},
+		{"map with extra keys", map[interface{}]interface{}{"a": 1}, map[interface{}]interface{}{"a": 1, "b": 2}, true},
+		{"map missing a key", map[interface{}]interface{}{"a": 1, "c": 3}, map[interface{}]interface{}{"a": 1, "b": 2}, false},
+		{"list in any order", []interface{}{2, 1}, []interface{}{1, 2, 3}, true},
+		{"list needing distinct elements", []interface{}{1, 1}, []interface{}{1, 2}, false},
+		{"map instead of list", []interface{}{1}, map[interface{}]interface{}{"a": 1}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := subsetMatches(tt.pattern, tt.actual); got != tt.want {
+				t.Errorf("subsetMatches(%v, %v) = %v, want %v", tt.pattern, tt.actual, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchListElements(t *testing.T) {
+	// The first pattern element is contained in both actual elements, the
+	// second only in the first one, so the first has to move to the second
+	pattern := []interface{}{
+		map[interface{}]interface{}{"name": "web"},
+		map[interface{}]interface{}{"name": "web", "port": 80},
+		map[interface{}]interface{}{"name": "db"},
+	}
+	actual := []interface{}{
+		map[interface{}]interface{}{"name": "web", "port": 80},
+		map[interface{}]interface{}{"name": "web", "port": 443},
+	}
+	if got, want := matchListElements(pattern, actual), []int{1, 0, -1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("matchListElements() = %v, want %v", got, want)
+	}
+}
+
+func TestCompareDocumentsSubset(t *testing.T) {
+	pattern := `metadata:
+  name: web
+spec:
+  ports: [80, 443]
+  containers:
+    - name: app
+    - name: sidecar
+`
+	actual := `metadata:
+  name: web
+  labels:
+    team: payments
+spec:
+  ports: [443, 8080, 80]
+  containers:
+    - name: app
+      image: app:1
+`
+	changes, err := Documents([]byte(pattern), []byte(actual), Options{Subset: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Change{{Path: ".spec.containers[1]", Kind: Removed, Left: map[interface{}]interface{}{"name": "sidecar"}}}
+	if len(changes) != 1 || changes[0].Path != want[0].Path || changes[0].Kind != want[0].Kind || !reflect.DeepEqual(changes[0].Left, want[0].Left) {
+		t.Errorf("changes = %+v, want %+v", changes, want)
+	}
+}
+
+func TestCompareDocumentsSubsetListKeys(t *testing.T) {
+	pattern := `containers: # yamldiff:list-key=name
+  - name: sidecar
+    image: !regex '^proxy:'
+  - name: app
+    image: app:2
+  - name: worker
+ports:
+  - port: 80
+  - port: 443
+`
+	actual := `containers:
+  - name: app
+    image: app:1
+  - name: sidecar
+    image: proxy:1.2
+  - name: debug
+ports:
+  - port: 443
+    protocol: TCP
+  - port: 80
+`
+	tests := []struct {
+		name    string
+		options Options
+		want    []string
+	}{
+		{"directive", Options{Subset: true}, []string{".containers[name=app].image", ".containers[name=worker]"}},
+		{"directive and flag", Options{Subset: true, ListKeys: map[string]string{".ports": "port"}}, []string{".containers[name=app].image", ".containers[name=worker]"}},
+		{"elements lacking the key", Options{Subset: true, ListKeys: map[string]string{".ports": "protocol"}}, []string{".containers[name=app].image", ".containers[name=worker]"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := Documents([]byte(pattern), []byte(actual), tt.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := changePaths(changes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("paths = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
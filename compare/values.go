@@ -0,0 +1,163 @@
+package compare
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// IsCollection reports whether a value is a non-empty map or list
+func IsCollection(val interface{}) bool {
+	switch v := val.(type) {
+	case map[interface{}]interface{}:
+		return len(v) > 0
+	case []interface{}:
+		return len(v) > 0
+	}
+	return false
+}
+
+// RenderValue renders a value as YAML, so strings that would otherwise be
+// ambiguous (such as "true", "1" or values with trailing whitespace) are quoted
+// and collections are shown the same way they appear in a YAML file. Floats
+// keep a fraction, so 1.0 stays distinguishable from the int 1.
+func RenderValue(val interface{}) string {
+	if m, ok := val.(*Matcher); ok {
+		return m.String()
+	}
+
+	node, err := ValueNode(val)
+	if err != nil {
+		return fmt.Sprint(val)
+	}
+
+	var buf bytes.Buffer
+	enc := yamlv3.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return fmt.Sprint(val)
+	}
+	enc.Close()
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// ValueNode converts a value into a YAML node tree with sorted map keys.
+// Floats written like ints get a fraction, as encoding them would otherwise
+// turn them into ints.
+func ValueNode(val interface{}) (*yamlv3.Node, error) {
+	switch v := val.(type) {
+	case map[interface{}]interface{}:
+		node := &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+		for _, key := range SortedKeys(v) {
+			keyNode, err := ValueNode(key)
+			if err != nil {
+				return nil, err
+			}
+			valNode, err := ValueNode(v[key])
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, keyNode, valNode)
+		}
+		return node, nil
+	case []interface{}:
+		node := &yamlv3.Node{Kind: yamlv3.SequenceNode, Tag: "!!seq"}
+		for _, item := range v {
+			itemNode, err := ValueNode(item)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, itemNode)
+		}
+		return node, nil
+	case *Matcher:
+		// Matchers are shown as written in the reference file
+		var doc yamlv3.Node
+		if err := yamlv3.Unmarshal([]byte(v.Source), &doc); err != nil || len(doc.Content) == 0 {
+			return nil, fmt.Errorf("invalid matcher %s", v.Source)
+		}
+		return doc.Content[0], nil
+	}
+
+	node := &yamlv3.Node{}
+	if err := node.Encode(val); err != nil {
+		return nil, err
+	}
+	if _, ok := val.(float64); ok && node.Tag == "!!int" {
+		node.Tag, node.Value = "!!float", node.Value+".0"
+	}
+	return node, nil
+}
+
+// JSONValue converts a YAML value into one encoding/json can marshal, turning
+// maps with arbitrary keys into maps with string keys
+func JSONValue(val interface{}) interface{} {
+	switch v := val.(type) {
+	case *Matcher:
+		return v.String()
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[fmt.Sprint(key)] = JSONValue(item)
+		}
+		return m
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = JSONValue(item)
+		}
+		return list
+	case float64:
+		// JSON has no infinity or NaN, they are kept as their YAML text
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return RenderValue(v)
+		}
+		// Floats keep a fraction, so they are not read back as ints
+		s := strconv.FormatFloat(v, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".e") {
+			s += ".0"
+		}
+		return json.Number(s)
+	}
+	return val
+}
+
+// InlineValue renders a value on a single line, collections as JSON
+func InlineValue(val interface{}) string {
+	if IsCollection(val) {
+		data, err := json.Marshal(JSONValue(val))
+		if err == nil {
+			return string(data)
+		}
+	}
+	return RenderScalar(val)
+}
+
+// RenderScalar renders a scalar on a single line, quoting multi-line strings
+// so every value stays on the line of its path
+func RenderScalar(val interface{}) string {
+	if s, ok := val.(string); ok && strings.ContainsAny(s, "\r\n") {
+		return strconv.Quote(s)
+	}
+	return RenderValue(val)
+}
+
+// ToFloat converts a numeric YAML value to a float64
+func ToFloat(val interface{}) (float64, bool) {
+	switch v := val.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+
+	"github.com/spf13/cobra"
+
+	"yamldiff/compare"
+)
+
+// resultChange is a change of a saved result together with its file
+type resultChange struct {
+	File   string         `json:"file"`
+	Change compare.Change `json:"change"`
+}
+
+// alteredChange is a change present in both results with different values
+type alteredChange struct {
+	File   string         `json:"file"`
+	Before compare.Change `json:"before"`
+	After  compare.Change `json:"after"`
+}
+
+// resultDelta lists how the changes of two saved results differ
+type resultDelta struct {
+	New      []resultChange  `json:"new"`
+	Resolved []resultChange  `json:"resolved"`
+	Altered  []alteredChange `json:"altered"`
+}
+
+// empty reports whether both results contain the same changes
+func (d *resultDelta) empty() bool {
+	return len(d.New) == 0 && len(d.Resolved) == 0 && len(d.Altered) == 0
+}
+
+// compareResults compares the changes of two reports by file and path.
+// Reports of a single file are matched regardless of the file name, so
+// results of comparing differently named files can be checked against each
+// other.
+func compareResults(before, after *report) *resultDelta {
+	singleFile := len(before.Files) == 1 && len(after.Files) == 1
+	fileKey := func(f fileReport) string {
+		if singleFile {
+			return ""
+		}
+		return f.Path
+	}
+
+	type changeKey struct{ file, path string }
+	beforeChanges := make(map[changeKey]compare.Change)
+	for _, f := range before.Files {
+		for _, ch := range f.Changes {
+			beforeChanges[changeKey{fileKey(f), ch.Path}] = ch
+		}
+	}
+
+	delta := &resultDelta{New: []resultChange{}, Resolved: []resultChange{}, Altered: []alteredChange{}}
+	seen := make(map[changeKey]bool)
+	for _, f := range after.Files {
+		for _, ch := range f.Changes {
+			key := changeKey{fileKey(f), ch.Path}
+			seen[key] = true
+
+			old, ok := beforeChanges[key]
+			switch {
+			case !ok:
+				delta.New = append(delta.New, resultChange{f.Path, ch})
+			case !sameChange(old, ch):
+				delta.Altered = append(delta.Altered, alteredChange{f.Path, old, ch})
+			}
+		}
+	}
+
+	for _, f := range before.Files {
+		for _, ch := range f.Changes {
+			if !seen[changeKey{fileKey(f), ch.Path}] {
+				delta.Resolved = append(delta.Resolved, resultChange{f.Path, ch})
+			}
+		}
+	}
+	return delta
+}
+
+// sameChange reports whether two changes have the same kind and values.
+// Their positions may differ, as unrelated edits move values around.
+func sameChange(a, b compare.Change) bool {
+	a.LeftRange, a.RightRange, b.LeftRange, b.RightRange = nil, nil, nil, nil
+	return reflect.DeepEqual(a, b)
+}
+
+// describeChange describes a change on a single line
+func describeChange(ch compare.Change) string {
+	switch ch.Kind {
+	case compare.Added:
+		return fmt.Sprintf("%s %s", ch.Kind, compare.InlineValue(ch.Right))
+	case compare.Removed:
+		return fmt.Sprintf("%s %s", ch.Kind, compare.InlineValue(ch.Left))
+	}
+	return fmt.Sprintf("%s %s → %s", ch.Kind, compare.InlineValue(ch.Left), compare.InlineValue(ch.Right))
+}
+
+// printDelta prints the differences between two results as text
+func printDelta(w io.Writer, delta *resultDelta, name1, name2 string) {
+	if delta.empty() {
+		fmt.Fprintln(w, "Both results contain the same changes.")
+		return
+	}
+
+	if len(delta.New) > 0 {
+		fmt.Fprintf(w, "\nNew changes (only in %s):\n", name2)
+		for _, rc := range delta.New {
+			fmt.Fprintf(w, "  %s %s: %s\n", rc.File, rc.Change.Path, describeChange(rc.Change))
+		}
+	}
+	if len(delta.Resolved) > 0 {
+		fmt.Fprintf(w, "\nResolved changes (only in %s):\n", name1)
+		for _, rc := range delta.Resolved {
+			fmt.Fprintf(w, "  %s %s: %s\n", rc.File, rc.Change.Path, describeChange(rc.Change))
+		}
+	}
+	if len(delta.Altered) > 0 {
+		fmt.Fprintf(w, "\nAltered changes:\n")
+		for _, ac := range delta.Altered {
+			fmt.Fprintf(w, "  %s %s\n", ac.File, ac.After.Path)
+			fmt.Fprintf(w, "    %s: %s\n", name1, describeChange(ac.Before))
+			fmt.Fprintf(w, "    %s: %s\n", name2, describeChange(ac.After))
+		}
+	}
+}
+
+// newCompareResultsCmd returns the compare-results command comparing the
+// changes of two saved results
+func newCompareResultsCmd() *cobra.Command {
+	var outputFormat string
+
+	cmd := &cobra.Command{
+		Use:   "compare-results before.json after.json",
+		Short: "Compare the changes of two results saved with --save-result.",
+		Long: `compare-results compares two results saved with --save-result and lists the
+changes that are new in the second result, resolved since the first result,
+or altered between them. Use it to check that the delta against a reference
+stays the same, e.g. after refactoring a config generator.
+
+Changes are matched by file and path. When both results cover a single file,
+the file names do not have to match. The command exits with status 1 when
+the results differ. Use -o json for machine-readable output.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			before, err := loadResult(args[0])
+			if err != nil {
+				return fmt.Errorf("error loading %s: %v", args[0], err)
+			}
+			after, err := loadResult(args[1])
+			if err != nil {
+				return fmt.Errorf("error loading %s: %v", args[1], err)
+			}
+
+			delta := compareResults(before.Report, after.Report)
+			switch outputFormat {
+			case "":
+				printDelta(os.Stdout, delta, args[0], args[1])
+			case "json":
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(delta); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("unknown output format %q, expected json", outputFormat)
+			}
+
+			if !delta.empty() {
+				os.Exit(1)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "", "Set the output format (json).")
+	cmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions([]string{"json"}, cobra.ShellCompDirectiveNoFileComp))
+	return cmd
+}
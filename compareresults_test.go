@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"yamldiff/compare"
+)
+
+func TestCompareResults(t *testing.T) {
+	replicas := compare.Change{Path: ".replicas", Kind: compare.Modified, Left: 1, Right: 2, LeftRange: &compare.Range{Line: 3}}
+	image := compare.Change{Path: ".image", Kind: compare.Modified, Left: "a", Right: "b"}
+	host := compare.Change{Path: ".hosts[=a]", Kind: compare.Removed, Left: "a"}
+	hostAgain := compare.Change{Path: ".hosts[=a#2]", Kind: compare.Removed, Left: "a"}
+	moved := replicas
+	moved.LeftRange = &compare.Range{Line: 7}
+	altered := image
+	altered.Right = "c"
+
+	before := &report{Files: []fileReport{{Path: "a.yaml", Changes: []compare.Change{replicas, image, host}}}}
+	after := &report{Files: []fileReport{{Path: "b.yaml", Changes: []compare.Change{moved, altered, host, hostAgain}}}}
+
+	delta := compareResults(before, after)
+	want := &resultDelta{
+		New:      []resultChange{{"b.yaml", hostAgain}},
+		Resolved: []resultChange{},
+		Altered:  []alteredChange{{"b.yaml", image, altered}},
+	}
+	if !reflect.DeepEqual(delta, want) {
+		t.Errorf("compareResults() = %+v, want %+v", delta, want)
+	}
+
+	var buf bytes.Buffer
+	printDelta(&buf, delta, "before.json", "after.json")
+	wantText := `
+New changes (only in after.json):
+  b.yaml .hosts[=a#2]: removed a
+
+Altered changes:
+  b.yaml .image
+    before.json: modified a → b
+    after.json: modified a → c
+`
+	if buf.String() != wantText {
+		t.Errorf("printDelta() =\n%s\nwant\n%s", buf.String(), wantText)
+	}
+
+	// Reports of several files match changes by file
+	before.Files = append(before.Files, fileReport{Path: "c.yaml", Changes: []compare.Change{image}})
+	after.Files[0].Path = "a.yaml"
+	delta = compareResults(before, after)
+	if len(delta.Resolved) != 1 || delta.Resolved[0].File != "c.yaml" {
+		t.Errorf("resolved = %+v, want the change of c.yaml", delta.Resolved)
+	}
+	if !compareResults(before, before).empty() {
+		t.Error("a result compared with itself is not empty")
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"yamldiff/compare"
+)
+
+// completionPaths returns the key paths of the YAML files given so far on
+// the command line, or only the paths of lists. Lists also yield a path with
+// [*] for all elements. Files that cannot be loaded are skipped.
+func completionPaths(args []string, lists bool) []string {
+	seen := make(map[string]bool)
+	for _, arg := range args {
+		doc, err := loadSource(arg, false)
+		if err != nil {
+			continue
+		}
+		for _, sel := range compare.SelectValues(doc.Content, "**") {
+			_, isList := sel.Value.([]interface{})
+			if lists && !isList {
+				continue
+			}
+			seen[sel.Path] = true
+			if isList && !lists {
+				seen[sel.Path+"[*]"] = true
+			}
+		}
+	}
+	delete(seen, "")
+
+	paths := make([]string, 0, len(seen))
+	for path := range seen {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// nextSegments returns the paths starting with prefix, cut after the segment
+// following it, so completion descends one level at a time
+func nextSegments(paths []string, prefix string) []string {
+	var candidates []string
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		rest := path[len(prefix):]
+		if end := strings.IndexAny(rest[min(1, len(rest)):], ".["); end >= 0 {
+			rest = rest[:end+1]
+		}
+		if candidate := prefix + rest; !seen[candidate] {
+			seen[candidate] = true
+			candidates = append(candidates, candidate)
+		}
+	}
+	return candidates
+}
+
+// completePaths suggests the key paths of the files given on the command line
+func completePaths(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return nextSegments(completionPaths(args, false), toComplete), cobra.ShellCompDirectiveNoSpace | cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeListKeys suggests the paths of lists in the files given on the
+// command line and, after the "=", the fields of their elements
+func completeListKeys(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	i := strings.LastIndex(toComplete, "=")
+	if i < 0 {
+		var candidates []string
+		for _, path := range nextSegments(completionPaths(args, true), toComplete) {
+			candidates = append(candidates, path+"=")
+		}
+		return candidates, cobra.ShellCompDirectiveNoSpace | cobra.ShellCompDirectiveNoFileComp
+	}
+
+	pattern := toComplete[:i]
+	seen := make(map[string]bool)
+	var candidates []string
+	for _, arg := range args {
+		doc, err := loadSource(arg, false)
+		if err != nil {
+			continue
+		}
+		for _, sel := range compare.SelectValues(doc.Content, pattern) {
+			list, _ := sel.Value.([]interface{})
+			for _, item := range list {
+				m, _ := item.(map[interface{}]interface{})
+				for _, key := range compare.SortedKeys(m) {
+					if candidate := pattern + "=" + fmt.Sprint(key); !seen[candidate] && !compare.IsCollection(m[key]) {
+						seen[candidate] = true
+						candidates = append(candidates, candidate)
+					}
+				}
+			}
+		}
+	}
+	return candidates, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeProfiles suggests the names of the profiles of the configuration
+// file selected with --config, or the default one
+func completeProfiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	configFile := defaultConfigFile
+	if globalFlags.config != "" {
+		configFile = globalFlags.config
+	}
+	cfg, err := loadConfig(configFile, false)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	var names []string
+	for _, prof := range cfg.Profiles {
+		names = append(names, prof.Name)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompletionPaths(t *testing.T) {
+	path := writeTemp(t, "a.yaml", "spec:\n  replicas: 1\n  containers:\n    - name: web\n      image: a\n")
+	args := []string{path, "missing.yaml"}
+
+	want := []string{".spec", ".spec.containers", ".spec.containers[*]", ".spec.containers[0]", ".spec.containers[0].image", ".spec.containers[0].name", ".spec.replicas"}
+	if got := completionPaths(args, false); !reflect.DeepEqual(got, want) {
+		t.Errorf("completionPaths() = %v, want %v", got, want)
+	}
+	if got := completionPaths(args, true); !reflect.DeepEqual(got, []string{".spec.containers"}) {
+		t.Errorf("completionPaths() of lists = %v, want [.spec.containers]", got)
+	}
+}
+
+func TestNextSegments(t *testing.T) {
+	paths := []string{".spec", ".spec.containers", ".spec.containers[0]", ".spec.containers[0].image", ".spec.replicas", ".status"}
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{"", []string{".spec", ".status"}},
+		{".sp", []string{".spec"}},
+		{".spec", []string{".spec", ".spec.containers", ".spec.replicas"}},
+		{".spec.containers", []string{".spec.containers", ".spec.containers[0]"}},
+		{".spec.containers[0].", []string{".spec.containers[0].image"}},
+	}
+
+	for _, tt := range tests {
+		if got := nextSegments(paths, tt.prefix); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("nextSegments(%q) = %v, want %v", tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestCompleteListKeys(t *testing.T) {
+	path := writeTemp(t, "a.yaml", "containers:\n  - name: web\n    ports: [80]\n  - name: db\n    id: 1\n")
+
+	got, _ := completeListKeys(nil, []string{path}, ".cont")
+	if want := []string{".containers="}; !reflect.DeepEqual(got, want) {
+		t.Errorf("completeListKeys() of paths = %v, want %v", got, want)
+	}
+	got, _ = completeListKeys(nil, []string{path}, ".containers=")
+	if want := []string{".containers=name", ".containers=id"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("completeListKeys() of fields = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"yamldiff/compare"
+)
+
+// defaultConfigFile is the configuration file looked up at the repository root
+const defaultConfigFile = ".yamldiff.yaml"
+
+// globalFlags holds the values of the flags shared by all commands
+var globalFlags struct {
+	// color is auto, always or never
+	color string
+	// config is the configuration file given with --config
+	config string
+	// verbosity is the number of times -v was given, at least 2 with --debug
+	verbosity int
+	// debug traces every decision of a comparison
+	debug bool
+}
+
+// verbosef prints a message to stderr when -v was given at least level times
+func verbosef(level int, format string, args ...interface{}) {
+	if globalFlags.verbosity >= level {
+		fmt.Fprintf(os.Stderr, "yamldiff: "+format+"\n", args...)
+	}
+}
+
+// timed prints how long a phase took since start at verbosity 1
+func timed(phase string, start time.Time) {
+	verbosef(1, "%s took %v", phase, time.Since(start).Round(time.Microsecond))
+}
+
+// config is the content of a yamldiff configuration file
+type config struct {
+	Profiles []profile `yaml:"profiles"`
+}
+
+// profile is a named set of comparison options applied to the files matching
+// one of its glob patterns
+type profile struct {
+	Name     string            `yaml:"name"`
+	Files    []string          `yaml:"files"`
+	Ignore   []string          `yaml:"ignore"`
+	ListKeys map[string]string `yaml:"listKeys"`
+}
+
+// loadConfig loads a configuration file. A missing file yields an empty
+// configuration unless the file is required.
+func loadConfig(filePath string, required bool) (*config, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if os.IsNotExist(err) && !required {
+		return &config{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg config
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return nil, fmt.Errorf("%s: %v", filePath, err)
+	}
+	return &cfg, nil
+}
+
+// loadGlobalConfig loads the configuration file given with --config, or
+// .yamldiff.yaml in the working directory when it exists
+func loadGlobalConfig() (*config, error) {
+	if globalFlags.config != "" {
+		return loadConfig(globalFlags.config, true)
+	}
+	return loadConfig(defaultConfigFile, false)
+}
+
+// profileFor returns the first profile with a pattern matching the slash
+// separated file name, or nil when no profile applies
+func (cfg *config) profileFor(name string) *profile {
+	for i := range cfg.Profiles {
+		for _, pattern := range cfg.Profiles[i].Files {
+			if compare.MatchGlob(pattern, name) {
+				return &cfg.Profiles[i]
+			}
+		}
+	}
+	return nil
+}
+
+// profileNamed returns the profile with the given name, or nil
+func (cfg *config) profileNamed(name string) *profile {
+	for i := range cfg.Profiles {
+		if cfg.Profiles[i].Name == name {
+			return &cfg.Profiles[i]
+		}
+	}
+	return nil
+}
+
+// options returns the comparison options of a profile. A nil profile yields
+// the default options.
+func (prof *profile) options() compare.Options {
+	if prof == nil {
+		return compare.Options{}
+	}
+	return compare.Options{Ignore: prof.Ignore, ListKeys: prof.ListKeys}
+}
+
+// withProfile returns the options of a request added to those of the named
+// profile. An empty name leaves the options unchanged.
+func withProfile(cfg *config, name string, options compare.Options) (compare.Options, error) {
+	if name == "" {
+		return options, nil
+	}
+	prof := cfg.profileNamed(name)
+	if prof == nil {
+		return compare.Options{}, fmt.Errorf("unknown profile %q", name)
+	}
+
+	options.Ignore = append(append([]string{}, prof.Ignore...), options.Ignore...)
+	if len(prof.ListKeys) > 0 {
+		listKeys := make(map[string]string, len(prof.ListKeys)+len(options.ListKeys))
+		for pattern, field := range prof.ListKeys {
+			listKeys[pattern] = field
+		}
+		for pattern, field := range options.ListKeys {
+			listKeys[pattern] = field
+		}
+		options.ListKeys = listKeys
+	}
+	return options, nil
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"yamldiff/compare"
+)
+
+// newDiffCmd returns the diff command comparing two YAML files, which is the
+// default command
+func newDiffCmd() *cobra.Command {
+	var outputFormat string
+	var lang string
+	var accessible bool
+	var noPager bool
+	var fullValues bool
+	var showWhitespace bool
+	var inlineDiff string
+	var leftRef string
+	var mergeBase bool
+	var saveResultFile string
+	var subset, positionalLists bool
+	var scalarLists string
+	var listKeys []string
+	var ignore, onlyPaths []string
+	var profileName string
+
+	cmd := &cobra.Command{
+		Use:   "diff file1.yaml file2.yaml",
+		Short: "Compare two YAML files and output the differences.",
+		Long: `diff compares two YAML files and shows the differences. It is the default
+command, so "yamldiff a.yaml b.yaml" is the same as "yamldiff diff a.yaml b.yaml".
+By default, it outputs the differences as YAML with additional formatting for clarity.
+You can choose other output format using the -o flag:
+
+- yaml: Outputs the differences as plain YAML without additional formatting.
+- yamldiff: Outputs the differences with an ASCII header and extra formatting for clarity.
+- markdown, html, json: Outputs the differences as a report table.
+
+Use --accessible for output that starts every line with a descriptive word
+(CHANGED, ADDED, REMOVED, BEFORE, AFTER) instead of relying on layout.
+Messages are shown in the language given by --lang or the LANG environment
+variable.
+
+When writing to a terminal, output that does not fit on the screen is shown
+through $PAGER (default "less -R") and long values are truncated to the
+terminal width. Use --no-pager and --full-values to disable this.
+
+Use --show-whitespace to make trailing spaces, tabs, line endings and
+invisible characters in differing strings visible and mark the exact
+characters that differ.
+
+Use --word-diff (or --word-diff=char) to show which words or characters
+changed within differing strings, marked as [-removed-] and {+added+}. The
+markdown and HTML reports always highlight them, by word unless
+--word-diff=char is given.
+
+Use --left-ref to read the first file from a git ref instead of the working
+tree, e.g. "yamldiff --left-ref origin/main config.yaml". When only one file
+is given, the same path is used for both sides. Add --merge-base to compare
+against the common ancestor of the ref and HEAD, showing only what the
+current branch changed.
+
+Use --subset to check that the first file is contained in the second one:
+every key and value of the first file must exist in the second, which may
+have additional keys. Every element of a list in the first file must match
+a distinct element of the list in the second, in any order, or the element
+with the same key for lists selected by --list-key or a list-key directive.
+Anything not contained is reported and the exit status is 1. Values in the
+first file may be replaced by matchers that tolerate volatile values:
+
+    !any              any value, the key only has to exist
+    !regex '^v\d+'    a string matching the regular expression
+    !type int         a value of the type string, int, float, number, bool,
+                      null, map or list
+    !range [1, 10]    a number between the bounds, inclusive
+
+Use --list-key pattern=field to match the elements of the lists selected by
+the pattern by a key field, so reordering them is not a change and
+differences are reported per element, e.g. --list-key .spec.containers=name
+reports .spec.containers[name=web].image. Without a list key, lists of maps
+are matched by a field inferred from their elements: name, id or key when
+every element has a distinct value for it, otherwise the field with distinct
+values pairing the most elements of both lists. -v prints the inferred keys.
+Other lists, and all lists with --positional-lists, are compared by position.
+
+Use --scalar-lists set to compare lists of scalars, like hosts or tags,
+regardless of order and report each element found in one file only, e.g.
+.hosts[=a.example.com]. Elements repeated in a list are reported as warnings.
+With --scalar-lists multiset every occurrence counts, so a repeated element
+is reported as added or removed, its later occurrences at paths like
+.hosts[=a.example.com#2].
+
+Use --ignore to skip the differences below a path pattern and --only-path to
+compare nothing but the paths matching a pattern. Patterns are key paths in
+which * matches any key, [*] any list index and ** any number of levels, e.g.
+--ignore '.metadata.annotations' --only-path '.spec.**.image'. --profile adds
+the options of a profile from .yamldiff.yaml or the file given with --config.
+
+The files may carry the same rules in comments, in either file:
+
+    replicas: 3  # yamldiff:ignore
+    # yamldiff:ignore-next
+    generated: abc
+    containers:  # yamldiff:list-key=name
+
+A directive applies to the value on its line or directly below it, and to
+that value only, even when its keys contain glob characters like *.
+
+Use --save-result to also write the full comparison to a JSON file, which
+"yamldiff render" can print again in any output format.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			// With --left-ref a single file is compared against its own history
+			if leftRef != "" {
+				return cobra.RangeArgs(1, 2)(cmd, args)
+			}
+			return cobra.ExactArgs(2)(cmd, args) // Expect exactly two arguments
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			file1 := args[0]
+			file2 := args[len(args)-1]
+
+			listKeyFields, err := compare.ParseListKeys(listKeys)
+			if err != nil {
+				log.Fatalf("Error: %v\n", err)
+			}
+			if err := compare.CheckScalarListMode(scalarLists); err != nil {
+				log.Fatalf("Error: %v\n", err)
+			}
+
+			var tracef func(format string, args ...interface{})
+			if globalFlags.verbosity >= 2 {
+				tracef = func(format string, args ...interface{}) { verbosef(2, format, args...) }
+			}
+
+			start := time.Now()
+			var data1 []byte
+			ref := leftRef
+			if leftRef != "" {
+				if mergeBase {
+					ref, err = gitMergeBase(filepath.Dir(file2), leftRef)
+					if err != nil {
+						log.Fatalf("Error finding merge base: %v\n", err)
+					}
+					verbosef(1, "merge base of %s and HEAD is %s", leftRef, ref)
+				}
+				data1, err = readSourceFromRef(ref, file1)
+			} else if mergeBase {
+				log.Fatalf("--merge-base requires --left-ref\n")
+			} else {
+				data1, err = ioutil.ReadFile(file1)
+			}
+			var root1 *yamlv3.Node
+			if err == nil {
+				root1, err = compare.Decode(data1)
+			}
+			if err != nil {
+				log.Fatalf("Error loading first file: %v\n", err)
+			}
+
+			data2, err := ioutil.ReadFile(file2)
+			var root2 *yamlv3.Node
+			if err == nil {
+				root2, err = compare.Decode(data2)
+			}
+			if err != nil {
+				log.Fatalf("Error loading second file: %v\n", err)
+			}
+			timed("load", start)
+
+			// Values are traced with the file they are read from
+			traceFile := func(name string) func(format string, args ...interface{}) {
+				if tracef == nil {
+					return nil
+				}
+				return func(format string, args ...interface{}) { tracef(format+" in %s", append(args, name)...) }
+			}
+			start = time.Now()
+			doc1, err := compare.Normalize(root1, subset, traceFile(file1))
+			if err != nil {
+				log.Fatalf("Error loading first file: %v\n", err)
+			}
+			doc2, err := compare.Normalize(root2, false, traceFile(file2))
+			if err != nil {
+				log.Fatalf("Error loading second file: %v\n", err)
+			}
+			timed("normalize", start)
+
+			options := compare.Options{Ignore: ignore, Only: onlyPaths, Subset: subset, ListKeys: listKeyFields, PositionalLists: positionalLists, ScalarLists: scalarLists}
+			if profileName != "" {
+				cfg, err := loadGlobalConfig()
+				if err != nil {
+					log.Fatalf("Error loading configuration: %v\n", err)
+				}
+				if options, err = withProfile(cfg, profileName, options); err != nil {
+					log.Fatalf("Error: %v\n", err)
+				}
+				verbosef(1, "using profile %s: ignore %v, list keys %v", profileName, options.Ignore, options.ListKeys)
+			}
+
+			c := &compare.Comparison{Options: options}
+			c.Warnf = func(format string, args ...interface{}) { verbosef(0, "warning: "+format, args...) }
+			c.Notef = func(format string, args ...interface{}) { verbosef(1, format, args...) }
+			c.Tracef = tracef
+			start = time.Now()
+			diffMap := c.Compare(doc1, doc2)
+			timed("compare", start)
+			verbosef(1, "found %d changes", len(c.Changes))
+
+			// A first file read from git is named like "git show" does, ref:path
+			input1, name1 := fileInput(file1), file1
+			if leftRef != "" {
+				input1 = inputInfo{Path: file1, Ref: ref}
+				name1 = leftRef + ":" + file1
+				if mergeBase {
+					name1 = shortRef(ref) + ":" + file1
+				}
+			}
+			r := &report{Base: name1, Head: file2, Files: []fileReport{
+				{Path: file2, OldPath: name1, Status: "modified", Options: c.Options, Changes: c.Changes},
+			}}
+			if saveResultFile != "" {
+				err := saveResult(saveResultFile, "diff", []inputInfo{input1, fileInput(file2)}, r)
+				if err != nil {
+					log.Fatalf("Error saving result: %v\n", err)
+				}
+			}
+
+			var output bytes.Buffer
+			p := &printer{w: &output, msg: catalogFor(lang), accessible: accessible, showWhitespace: showWhitespace}
+			p.color, _ = useColor(globalFlags.color)
+			switch inlineDiff {
+			case "", "word", "char":
+				p.inlineDiff = inlineDiff
+			default:
+				log.Fatalf("Invalid --word-diff mode %q, expected word or char\n", inlineDiff)
+			}
+			if width, _, ok := terminalSize(); ok && !fullValues {
+				p.width = width
+			}
+
+			start = time.Now()
+			if err := p.printResult(r, diffMap, outputFormat); err != nil {
+				log.Fatalf("Error printing result: %v\n", err)
+			}
+
+			timed("render", start)
+			if err := writeOutput(output.Bytes(), !noPager); err != nil {
+				log.Fatalf("Error writing output: %v\n", err)
+			}
+
+			// In subset mode the differences are assertion failures
+			if subset && len(c.Changes) > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+
+	// Adding the output format flag
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "", "Set the output format ("+strings.Join(outputFormats, ", ")+").")
+	cmd.Flags().StringVar(&lang, "lang", "", "Language for messages (en, de, es). Defaults to the LANG environment variable.")
+	cmd.Flags().BoolVar(&noPager, "no-pager", false, "Do not pipe output through $PAGER.")
+	cmd.Flags().BoolVar(&fullValues, "full-values", false, "Do not truncate long values to the terminal width.")
+	cmd.Flags().BoolVar(&showWhitespace, "show-whitespace", false, "Make whitespace and invisible characters in differing strings visible.")
+	cmd.Flags().StringVar(&inlineDiff, "word-diff", "", "Show changes within differing strings by word or char.")
+	cmd.Flags().Lookup("word-diff").NoOptDefVal = "word"
+	cmd.Flags().StringVar(&leftRef, "left-ref", "", "Read the first file from this git ref instead of the working tree.")
+	cmd.Flags().BoolVar(&mergeBase, "merge-base", false, "Use the merge base of --left-ref and HEAD as the first version.")
+	cmd.Flags().StringArrayVar(&ignore, "ignore", nil, "Do not report differences below paths matching this pattern.")
+	cmd.Flags().StringArrayVar(&onlyPaths, "only-path", nil, "Only compare the paths matching this pattern.")
+	cmd.Flags().StringVar(&profileName, "profile", "", "Add the options of this profile of the configuration file.")
+	cmd.Flags().StringArrayVar(&listKeys, "list-key", nil, "Match the elements of lists selected by a pattern by a key field (pattern=field).")
+	cmd.Flags().BoolVar(&positionalLists, "positional-lists", false, "Compare lists without a list key by position instead of inferring a key.")
+	cmd.Flags().StringVar(&scalarLists, "scalar-lists", "position", "Compare lists of scalars by position, as a set or as a multiset ("+strings.Join(compare.ScalarListModes, ", ")+").")
+	cmd.Flags().BoolVar(&subset, "subset", false, "Check that the first file is contained in the second one.")
+	cmd.Flags().StringVar(&saveResultFile, "save-result", "", "Write the structured comparison result to this JSON file.")
+	cmd.Flags().BoolVar(&accessible, "accessible", false, "Start every output line with a descriptive word instead of relying on layout.")
+
+	// Suggest flag values, including key paths of the files given so far
+	cmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions(outputFormats, cobra.ShellCompDirectiveNoFileComp))
+	cmd.RegisterFlagCompletionFunc("lang", cobra.FixedCompletions([]string{"en", "de", "es"}, cobra.ShellCompDirectiveNoFileComp))
+	cmd.RegisterFlagCompletionFunc("word-diff", cobra.FixedCompletions([]string{"word", "char"}, cobra.ShellCompDirectiveNoFileComp))
+	cmd.RegisterFlagCompletionFunc("ignore", completePaths)
+	cmd.RegisterFlagCompletionFunc("only-path", completePaths)
+	cmd.RegisterFlagCompletionFunc("list-key", completeListKeys)
+	cmd.RegisterFlagCompletionFunc("profile", completeProfiles)
+	cmd.RegisterFlagCompletionFunc("scalar-lists", cobra.FixedCompletions(compare.ScalarListModes, cobra.ShellCompDirectiveNoFileComp))
+	return cmd
+}
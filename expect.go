@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"regexp"
+
+	"github.com/spf13/cobra"
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"yamldiff/compare"
+)
+
+// expectation is an assertion about the values selected by a path pattern.
+// Every assertion that is set has to hold for every selected value.
+type expectation struct {
+	Path    string        `yaml:"path"`
+	Equals  yamlv3.Node   `yaml:"equals"`
+	Matches string        `yaml:"matches"`
+	Exists  bool          `yaml:"exists"`
+	Absent  bool          `yaml:"absent"`
+	Range   []interface{} `yaml:"range"`
+
+	// expected is the value of Equals, which may contain matchers
+	expected  interface{}
+	hasEquals bool
+}
+
+// expectationsFile is the content of an --expect file
+type expectationsFile struct {
+	Expectations []expectation `yaml:"expectations"`
+}
+
+// failedExpectation describes an assertion that did not hold
+type failedExpectation struct {
+	Path    string
+	Message string
+	Actual  interface{}
+	// HasActual is false when no value exists at the path
+	HasActual bool
+}
+
+// loadExpectations loads and validates an expectations file
+func loadExpectations(filePath string) ([]expectation, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decode with yaml.v3 to keep the tags of matchers in equals values
+	var file expectationsFile
+	decoder := yamlv3.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	for i := range file.Expectations {
+		exp := &file.Expectations[i]
+		if exp.Path == "" {
+			return nil, fmt.Errorf("expectation %d has no path", i+1)
+		}
+		if exp.Equals.Kind != 0 {
+			exp.hasEquals = true
+			if exp.expected, err = compare.NodeValue(&exp.Equals, true); err != nil {
+				return nil, fmt.Errorf("expectation for %s: %v", exp.Path, err)
+			}
+		}
+		if exp.Matches != "" {
+			if _, err := regexp.Compile(exp.Matches); err != nil {
+				return nil, fmt.Errorf("expectation for %s: %v", exp.Path, err)
+			}
+		}
+		if exp.Range != nil {
+			if len(exp.Range) != 2 {
+				return nil, fmt.Errorf("expectation for %s: range needs a minimum and a maximum", exp.Path)
+			}
+			if _, ok := compare.ToFloat(exp.Range[0]); !ok {
+				return nil, fmt.Errorf("expectation for %s: range bounds must be numbers", exp.Path)
+			}
+			if _, ok := compare.ToFloat(exp.Range[1]); !ok {
+				return nil, fmt.Errorf("expectation for %s: range bounds must be numbers", exp.Path)
+			}
+		}
+	}
+	return file.Expectations, nil
+}
+
+// check evaluates an expectation against a YAML tree and returns the
+// assertions that failed
+func (exp expectation) check(content interface{}) []failedExpectation {
+	selected := compare.SelectValues(content, exp.Path)
+	if exp.Absent {
+		var failed []failedExpectation
+		for _, sel := range selected {
+			failed = append(failed, failedExpectation{sel.Path, "expected to be absent", sel.Value, true})
+		}
+		return failed
+	}
+
+	if len(selected) == 0 {
+		// A path that does not exist fails every assertion about its value
+		if exp.Exists || exp.hasEquals || exp.Matches != "" || exp.Range != nil {
+			return []failedExpectation{{Path: exp.Path, Message: "expected to exist"}}
+		}
+		return nil
+	}
+
+	var failed []failedExpectation
+	for _, sel := range selected {
+		fail := func(format string, args ...interface{}) {
+			failed = append(failed, failedExpectation{sel.Path, fmt.Sprintf(format, args...), sel.Value, true})
+		}
+
+		if exp.hasEquals && !valuesEqual(exp.expected, sel.Value) {
+			fail("expected to equal %s", compare.InlineValue(exp.expected))
+		}
+		if exp.Matches != "" {
+			s, ok := sel.Value.(string)
+			if !ok {
+				s = compare.RenderScalar(sel.Value)
+			}
+			if compare.IsCollection(sel.Value) || !regexp.MustCompile(exp.Matches).MatchString(s) {
+				fail("expected to match %s", exp.Matches)
+			}
+		}
+		if exp.Range != nil {
+			min, _ := compare.ToFloat(exp.Range[0])
+			max, _ := compare.ToFloat(exp.Range[1])
+			if n, ok := compare.ToFloat(sel.Value); !ok || n < min || n > max {
+				fail("expected to be in range [%v, %v]", exp.Range[0], exp.Range[1])
+			}
+		}
+	}
+	return failed
+}
+
+// valuesEqual reports whether an expected value equals an actual value,
+// treating integers and floats with the same value as equal. Matchers in the
+// expected value accept any value they match.
+func valuesEqual(expected, actual interface{}) bool {
+	switch e := expected.(type) {
+	case *compare.Matcher:
+		return e.Matches(actual)
+	case map[interface{}]interface{}:
+		a, ok := actual.(map[interface{}]interface{})
+		if !ok || len(a) != len(e) {
+			return false
+		}
+		for key, val := range e {
+			actualVal, ok := a[key]
+			if !ok || !valuesEqual(val, actualVal) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		if !ok || len(a) != len(e) {
+			return false
+		}
+		for i := range e {
+			if !valuesEqual(e[i], a[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if a, ok := compare.ToFloat(expected); ok {
+		b, ok := compare.ToFloat(actual)
+		return ok && a == b
+	}
+	return reflect.DeepEqual(expected, actual)
+}
+
+// printFailedExpectations prints every failed assertion with the actual value
+func printFailedExpectations(w io.Writer, failed []failedExpectation) {
+	for _, f := range failed {
+		fmt.Fprintf(w, "FAIL %s: %s\n", f.Path, f.Message)
+		if f.HasActual {
+			fmt.Fprintf(w, "  actual: %s\n", compare.InlineValue(f.Actual))
+		}
+	}
+}
+
+// newExpectCmd returns the expect command checking a YAML file against a
+// list of path assertions
+func newExpectCmd() *cobra.Command {
+	var expectFile string
+
+	cmd := &cobra.Command{
+		Use:   "expect actual.yaml --expect expectations.yaml",
+		Short: "Check a YAML file against path assertions.",
+		Long: `expect checks the values of a YAML file against the assertions listed in an
+expectations file and reports every failed assertion with the actual value.
+It exits with status 1 when an assertion fails.
+
+Paths may contain wildcards: * matches any key, [*] any list index and **
+any number of keys. An assertion applies to every value its path selects.
+
+    expectations:
+      - path: .spec.replicas
+        range: [2, 10]
+      - path: .metadata.name
+        matches: "^payments-"
+      - path: .spec.template.spec.containers[*].imagePullPolicy
+        equals: IfNotPresent
+      - path: .spec.template.spec.hostNetwork
+        absent: true
+      - path: .metadata.labels.team
+        exists: true
+      - path: .spec.template.spec.containers[*].image
+        equals: !regex '^registry.example.com/'
+
+Values given to equals may contain the matchers !any, !regex, !type and
+!range, as described for --subset.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			doc, err := loadSource(args[0], false)
+			if err != nil {
+				return fmt.Errorf("error loading file: %v", err)
+			}
+			expectations, err := loadExpectations(expectFile)
+			if err != nil {
+				return fmt.Errorf("error loading expectations: %v", err)
+			}
+
+			var failed []failedExpectation
+			for _, exp := range expectations {
+				failed = append(failed, exp.check(doc.Content)...)
+			}
+
+			printFailedExpectations(os.Stdout, failed)
+			if len(failed) > 0 {
+				fmt.Printf("\n%d failed assertions in %d expectations.\n", len(failed), len(expectations))
+				os.Exit(1)
+			}
+			fmt.Printf("All %d expectations met.\n", len(expectations))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&expectFile, "expect", "", "File listing the expected values.")
+	cmd.MarkFlagRequired("expect")
+	return cmd
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"yamldiff/compare"
+)
+
+func TestExpectations(t *testing.T) {
+	path := writeTemp(t, "expect.yaml", `expectations:
+  - path: .spec.replicas
+    range: [2, 10]
+  - path: .metadata.name
+    matches: "^payments-"
+  - path: .spec.containers[*].imagePullPolicy
+    equals: IfNotPresent
+  - path: .spec.hostNetwork
+    absent: true
+  - path: .metadata.labels.team
+    exists: true
+  - path: .spec.containers[*].image
+    equals: !regex '^registry.example.com/'
+  - path: .spec.ratio
+    equals: 1
+`)
+	expectations, err := loadExpectations(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := compare.Parse([]byte(`metadata:
+  name: orders-api
+spec:
+  replicas: 12
+  hostNetwork: false
+  ratio: 1.0
+  containers:
+    - image: registry.example.com/app
+      imagePullPolicy: Always
+    - image: docker.io/sidecar
+      imagePullPolicy: IfNotPresent
+`), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var failed []failedExpectation
+	for _, exp := range expectations {
+		failed = append(failed, exp.check(doc.Content)...)
+	}
+	want := []failedExpectation{
+		{".spec.replicas", "expected to be in range [2, 10]", 12, true},
+		{".metadata.name", "expected to match ^payments-", "orders-api", true},
+		{".spec.containers[0].imagePullPolicy", "expected to equal IfNotPresent", "Always", true},
+		{".spec.hostNetwork", "expected to be absent", false, true},
+		{".metadata.labels.team", "expected to exist", nil, false},
+		{".spec.containers[1].image", "expected to equal !regex '^registry.example.com/'", "docker.io/sidecar", true},
+	}
+	if !reflect.DeepEqual(failed, want) {
+		t.Errorf("failed expectations =\n%+v\nwant\n%+v", failed, want)
+	}
+}
+
+func TestLoadExpectationsErrors(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"expectations:\n  - exists: true\n", "has no path"},
+		{"expectations:\n  - path: .a\n    matches: '['\n", "missing closing ]"},
+		{"expectations:\n  - path: .a\n    range: [1]\n", "needs a minimum and a maximum"},
+		{"expectations:\n  - path: .a\n    range: [a, 2]\n", "must be numbers"},
+		{"expectations:\n  - path: .a\n    equals: !regex '['\n", "missing closing ]"},
+		{"expectations:\n  - path: .a\n    unknown: 1\n", "not found"},
+	}
+
+	for _, tt := range tests {
+		_, err := loadExpectations(writeTemp(t, "expect.yaml", tt.content))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("loadExpectations(%q) error = %v, want one containing %q", tt.content, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/spf13/cobra"
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"yamldiff/compare"
+)
+
+// formatYAML encodes every document of a YAML stream again with the given
+// indentation, keeping comments, key order and the style of scalars. The
+// result is verified to decode to the same values, so formatting never
+// changes the content. A stream without documents is returned unchanged.
+func formatYAML(data []byte, indent int) ([]byte, error) {
+	docs, err := compare.DecodeDocuments(data)
+	if err != nil || len(docs) == 0 {
+		return data, err
+	}
+
+	var buf bytes.Buffer
+	enc := yamlv3.NewEncoder(&buf)
+	enc.SetIndent(indent)
+	for _, doc := range docs {
+		if err := enc.Encode(doc); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+
+	written, err := compare.DecodeDocuments(buf.Bytes())
+	if err != nil || len(written) != len(docs) {
+		return nil, fmt.Errorf("the formatted file does not read back as the same documents")
+	}
+	for i := range docs {
+		if !sameNode(documentRoot(docs[i]), documentRoot(written[i])) {
+			return nil, fmt.Errorf("the formatted file does not read back as the same values")
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// documentRoot returns the top level node of a document, or nil when it is
+// empty
+func documentRoot(doc *yamlv3.Node) *yamlv3.Node {
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	return doc.Content[0]
+}
+
+// newFmtCmd returns the fmt command formatting YAML files
+func newFmtCmd() *cobra.Command {
+	var write, list bool
+	var indent int
+
+	cmd := &cobra.Command{
+		Use:   "fmt [file...]",
+		Short: "Format YAML files consistently.",
+		Long: `fmt formats YAML files with a consistent indentation, keeping comments, key
+order and the quoting of values. Every file is checked to hold the same
+values once formatted. The formatted files are printed, or written back with
+-w. With -l only the names of the files whose formatting differs are
+printed, e.g. to check formatting in CI:
+
+    test -z "$(yamldiff fmt -l config/*.yaml)"
+
+Without files, fmt formats standard input.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			if indent < 1 {
+				return fmt.Errorf("invalid --indent %d, expected a positive number", indent)
+			}
+
+			if len(args) == 0 {
+				data, err := ioutil.ReadAll(os.Stdin)
+				if err != nil {
+					return fmt.Errorf("error reading standard input: %v", err)
+				}
+				formatted, err := formatYAML(data, indent)
+				if err != nil {
+					return fmt.Errorf("error formatting standard input: %v", err)
+				}
+				os.Stdout.Write(formatted)
+				return nil
+			}
+
+			for _, name := range args {
+				data, err := ioutil.ReadFile(name)
+				if err != nil {
+					return fmt.Errorf("error loading file: %v", err)
+				}
+				formatted, err := formatYAML(data, indent)
+				if err != nil {
+					return fmt.Errorf("error formatting %s: %v", name, err)
+				}
+
+				changed := !bytes.Equal(data, formatted)
+				if list && changed {
+					fmt.Println(name)
+				}
+				if write && changed {
+					if err := ioutil.WriteFile(name, formatted, 0644); err != nil {
+						return fmt.Errorf("error writing %s: %v", name, err)
+					}
+				}
+				if !list && !write {
+					os.Stdout.Write(formatted)
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVarP(&write, "write", "w", false, "Write the formatted content back to the files.")
+	cmd.Flags().BoolVarP(&list, "list", "l", false, "Print the names of the files whose formatting differs.")
+	cmd.Flags().IntVar(&indent, "indent", 2, "Number of spaces to indent nested values with.")
+	return cmd
+}
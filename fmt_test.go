@@ -0,0 +1,53 @@
+package main
+
+import "testing"
+
+func TestFormatYAML(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		indent int
+		want   string
+	}{
+		{
+			name:   "indentation and comments",
+			input:  "# top\na:\n    b: 1   # c\n    list:\n    -   x\n    -   'y'\n",
+			indent: 2,
+			want:   "# top\na:\n  b: 1 # c\n  list:\n    - x\n    - 'y'\n",
+		},
+		{
+			name:   "several documents",
+			input:  "a: 1\n---\nb:   [1,2]\n",
+			indent: 4,
+			want:   "a: 1\n---\nb: [1, 2]\n",
+		},
+		{
+			name:   "wider indentation",
+			input:  "a:\n  b: 1\n",
+			indent: 4,
+			want:   "a:\n    b: 1\n",
+		},
+		{
+			name:   "only comments",
+			input:  "# nothing\n",
+			indent: 2,
+			want:   "# nothing\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := formatYAML([]byte(tt.input), tt.indent)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("formatYAML() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := formatYAML([]byte("a: [\n"), 2); err == nil {
+		t.Error("expected an error for invalid YAML")
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"yamldiff/compare"
+)
+
+// fileStatus is a file changed between two git refs
+type fileStatus struct {
+	// Status is added, deleted, modified, renamed or copied
+	Status  string
+	Path    string
+	OldPath string
+}
+
+// gitStatusNames maps the status letters of git diff --name-status to names
+var gitStatusNames = map[byte]string{
+	'A': "added",
+	'C': "copied",
+	'D': "deleted",
+	'M': "modified",
+	'R': "renamed",
+	'T': "modified",
+}
+
+// gitCommand runs git with the given arguments and returns its output
+func gitCommand(args ...string) ([]byte, error) {
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("git %s: %v", args[0], err)
+	}
+	return out, nil
+}
+
+// gitOutput runs git with the given arguments and returns its trimmed output
+func gitOutput(args ...string) (string, error) {
+	out, err := gitCommand(args...)
+	return strings.TrimSpace(string(out)), err
+}
+
+// gitRoot returns the top level directory of the repository containing dir
+func gitRoot(dir string) (string, error) {
+	return gitOutput("-C", dir, "rev-parse", "--show-toplevel")
+}
+
+// gitMergeBase returns the best common ancestor of ref and HEAD in the
+// repository containing dir
+func gitMergeBase(dir, ref string) (string, error) {
+	return gitOutput("-C", dir, "merge-base", ref, "HEAD")
+}
+
+// shortRef abbreviates a commit hash like git does and leaves other refs
+// unchanged
+func shortRef(ref string) string {
+	if len(ref) == 40 && strings.Trim(ref, "0123456789abcdef") == "" {
+		return ref[:12]
+	}
+	return ref
+}
+
+// gitReadFile returns the content of a file at a ref. The path is relative to
+// the repository root.
+func gitReadFile(root, ref, relPath string) ([]byte, error) {
+	return gitCommand("-C", root, "show", ref+":"+filepath.ToSlash(relPath))
+}
+
+// gitChangedFiles lists the files changed on head since it diverged from
+// base, like the file list of a pull request
+func gitChangedFiles(root, base, head string) ([]fileStatus, error) {
+	out, err := gitCommand("-C", root, "diff", "--name-status", "-z", "-M", base+"..."+head, "--")
+	if err != nil {
+		return nil, err
+	}
+
+	// Entries are NUL separated: status, path, and a second path for renames and copies
+	var files []fileStatus
+	fields := bytes.Split(bytes.TrimSuffix(out, []byte{0}), []byte{0})
+	for i := 0; i+1 < len(fields); i += 2 {
+		status := string(fields[i])
+		file := fileStatus{Status: gitStatusNames[status[0]], Path: string(fields[i+1])}
+		if file.Status == "renamed" || file.Status == "copied" {
+			if i+2 >= len(fields) {
+				break
+			}
+			file.OldPath, file.Path = file.Path, string(fields[i+2])
+			i++
+		}
+		if file.Status == "" {
+			file.Status = "modified"
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// loadSourceFromRef loads the version of a working tree file stored at a git
+// ref. In subset mode the file may contain matchers.
+func loadSourceFromRef(ref, filePath string, matchers bool) (*compare.Document, error) {
+	data, err := readSourceFromRef(ref, filePath)
+	if err != nil {
+		return nil, err
+	}
+	return compare.Parse(data, matchers)
+}
+
+// readSourceFromRef reads the version of a working tree file stored at a git
+// ref. The path is resolved relative to the repository containing the file,
+// so it does not have to exist in the working tree.
+func readSourceFromRef(ref, filePath string) ([]byte, error) {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(absPath)
+	root, err := gitRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resolve symlinks on both sides, git reports the physical top level
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		absPath = filepath.Join(resolved, filepath.Base(absPath))
+	}
+	relPath, err := filepath.Rel(root, absPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return gitReadFile(root, ref, relPath)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"yamldiff/compare"
+)
+
+// gitRepo creates a git repository with a committed file and returns the
+// path of the file
+func gitRepo(t *testing.T, name, content string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", name},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "add " + name},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	return path
+}
+
+func TestLoadSourceFromRef(t *testing.T) {
+	path := gitRepo(t, "c.yaml", "version: !regex '^v\\d+'\nreplicas: 1\n")
+	if err := os.WriteFile(path, []byte("version: v12\nreplicas: 2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := loadSourceFromRef("HEAD", path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := doc.Content["replicas"]; got != 1 {
+		t.Errorf("replicas = %v, want the committed 1", got)
+	}
+	if _, ok := doc.Content["version"].(string); !ok {
+		t.Errorf("version = %#v, want a string without subset mode", doc.Content["version"])
+	}
+
+	doc, err = loadSourceFromRef("HEAD", path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, ok := doc.Content["version"].(*compare.Matcher)
+	if !ok {
+		t.Fatalf("version = %#v, want a matcher in subset mode", doc.Content["version"])
+	}
+	if !m.Matches("v12") || m.Matches("12") {
+		t.Errorf("matcher %s does not check the version", m)
+	}
+
+	if _, err := loadSourceFromRef("HEAD", filepath.Join(filepath.Dir(path), "missing.yaml"), false); err == nil {
+		t.Error("expected an error for a file missing at the ref")
+	}
+}
+
+func TestShortRef(t *testing.T) {
+	tests := map[string]string{
+		"03ab6f469ba5c0d4e8e2a1b3c4d5e6f708192a3b": "03ab6f469ba5",
+		"origin/main": "origin/main",
+		"03ab6f4":     "03ab6f4",
+	}
+	for ref, want := range tests {
+		if got := shortRef(ref); got != want {
+			t.Errorf("shortRef(%q) = %q, want %q", ref, got, want)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"yamldiff/compare"
+	pb "yamldiff/yamldiffpb"
+)
+
+// changeKinds maps change kinds to their protocol buffer enum values
+var changeKinds = map[compare.Kind]pb.ChangeKind{
+	compare.Modified: pb.ChangeKind_MODIFIED,
+	compare.Added:    pb.ChangeKind_ADDED,
+	compare.Removed:  pb.ChangeKind_REMOVED,
+}
+
+// diffServer implements the yamldiff gRPC service
+type diffServer struct {
+	pb.UnimplementedDiffServer
+	cfg *config
+}
+
+// newGRPCServer returns a gRPC server offering the yamldiff service
+func newGRPCServer(cfg *config) *grpc.Server {
+	server := grpc.NewServer()
+	pb.RegisterDiffServer(server, &diffServer{cfg: cfg})
+	return server
+}
+
+// protoValue converts a YAML value into a protocol buffer value. Values
+// without a JSON equivalent are converted to their string representation.
+func protoValue(val interface{}) *structpb.Value {
+	v := &structpb.Value{}
+	data, err := json.Marshal(compare.JSONValue(val))
+	if err != nil || protojson.Unmarshal(data, v) != nil {
+		return structpb.NewStringValue(fmt.Sprint(val))
+	}
+	return v
+}
+
+// protoRange converts the position of a value into its protocol buffer
+// message
+func protoRange(r *compare.Range) *pb.Range {
+	if r == nil {
+		return nil
+	}
+	return &pb.Range{Line: int32(r.Line), Column: int32(r.Column), EndLine: int32(r.EndLine), EndColumn: int32(r.EndColumn)}
+}
+
+// protoChanges converts changes into their protocol buffer messages
+func protoChanges(changes []compare.Change) []*pb.Change {
+	messages := make([]*pb.Change, len(changes))
+	for i, ch := range changes {
+		messages[i] = &pb.Change{Path: ch.Path, Kind: changeKinds[ch.Kind], LeftRange: protoRange(ch.LeftRange), RightRange: protoRange(ch.RightRange)}
+		if ch.Kind != compare.Added {
+			messages[i].Left = protoValue(ch.Left)
+		}
+		if ch.Kind != compare.Removed {
+			messages[i].Right = protoValue(ch.Right)
+		}
+	}
+	return messages
+}
+
+// compare runs the comparison of a request
+func (s *diffServer) compare(req *pb.CompareRequest) ([]compare.Change, error) {
+	var options compare.Options
+	if o := req.GetOptions(); o != nil {
+		options = compare.Options{Ignore: o.Ignore, ListKeys: o.ListKeys, MissingKeys: o.MissingKeys, Subset: o.Subset, PositionalLists: o.PositionalLists, ScalarLists: o.ScalarLists}
+	}
+	options, err := withProfile(s.cfg, req.GetProfile(), options)
+	if err != nil {
+		return nil, err
+	}
+	return compare.Documents([]byte(req.GetLeft()), []byte(req.GetRight()), options)
+}
+
+// Compare compares two YAML documents
+func (s *diffServer) Compare(ctx context.Context, req *pb.CompareRequest) (*pb.CompareResponse, error) {
+	changes, err := s.compare(req)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &pb.CompareResponse{Changes: protoChanges(changes)}, nil
+}
+
+// CompareBatch compares the pairs of a batch concurrently and streams each
+// result as soon as it is done. The comparison stops when the client goes
+// away.
+func (s *diffServer) CompareBatch(req *pb.CompareBatchRequest, stream pb.Diff_CompareBatchServer) error {
+	ctx := stream.Context()
+	pairs := req.GetPairs()
+	indexes := make(chan int)
+	results := make(chan *pb.BatchResult)
+
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				result := &pb.BatchResult{Index: int32(i), Name: pairs[i].GetName(), Labels: pairs[i].GetLabels()}
+				if changes, err := s.compare(pairs[i].GetRequest()); err != nil {
+					result.Error = err.Error()
+				} else {
+					result.Changes = protoChanges(changes)
+				}
+
+				select {
+				case results <- result:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(indexes)
+		for i := range pairs {
+			select {
+			case indexes <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	for result := range results {
+		if err := stream.Send(result); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	pb "yamldiff/yamldiffpb"
+)
+
+// testDiffClient starts the gRPC service on an in-memory listener and
+// returns a client connected to it
+func testDiffClient(t *testing.T, cfg *config) pb.DiffClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	server := newGRPCServer(cfg)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewDiffClient(conn)
+}
+
+func TestGRPCCompare(t *testing.T) {
+	cfg := &config{Profiles: []profile{{Name: "k8s", Ignore: []string{".metadata.**"}}}}
+	client := testDiffClient(t, cfg)
+	ctx := context.Background()
+
+	resp, err := client.Compare(ctx, &pb.CompareRequest{
+		Left:    "metadata: {uid: a}\nspec: {replicas: 1, image: web}\nextra: 1\n",
+		Right:   "metadata: {uid: b}\nspec: {replicas: 2, image: web}\n",
+		Profile: "k8s",
+		Options: &pb.Options{MissingKeys: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes := resp.GetChanges()
+	if len(changes) != 2 {
+		t.Fatalf("got %d changes, want 2: %v", len(changes), changes)
+	}
+	if ch := changes[0]; ch.Path != ".extra" || ch.Kind != pb.ChangeKind_REMOVED || ch.Left.GetNumberValue() != 1 || ch.Right != nil {
+		t.Errorf("first change = %v, want .extra removed", ch)
+	}
+	if ch := changes[1]; ch.Path != ".spec.replicas" || ch.Kind != pb.ChangeKind_MODIFIED || ch.Left.GetNumberValue() != 1 || ch.Right.GetNumberValue() != 2 {
+		t.Errorf("second change = %v, want .spec.replicas modified", ch)
+	}
+	if r := changes[1].GetRightRange(); r.GetLine() != 2 {
+		t.Errorf("right range = %v, want line 2", r)
+	}
+
+	for _, req := range []*pb.CompareRequest{
+		{Left: "a: [\n", Right: "a: 1\n"},
+		{Left: "a: 1\n", Right: "a: 1\n", Profile: "missing"},
+	} {
+		if _, err := client.Compare(ctx, req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Compare(%v) error = %v, want InvalidArgument", req, err)
+		}
+	}
+}
+
+func TestGRPCCompareBatch(t *testing.T) {
+	client := testDiffClient(t, &config{})
+
+	var pairs []*pb.BatchPair
+	for i := 0; i < 20; i++ {
+		left := fmt.Sprintf("n: %d\n", i)
+		if i%5 == 0 {
+			left = "n: [\n"
+		}
+		pairs = append(pairs, &pb.BatchPair{
+			Name:    fmt.Sprintf("pair-%d", i),
+			Labels:  map[string]string{"i": fmt.Sprint(i)},
+			Request: &pb.CompareRequest{Left: left, Right: "n: 0\n"},
+		})
+	}
+
+	stream, err := client.CompareBatch(context.Background(), &pb.CompareBatchRequest{Pairs: pairs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[int32]bool)
+	for {
+		result, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Results arrive as they are done, so they are matched by index
+		i := result.GetIndex()
+		if seen[i] {
+			t.Errorf("result %d received twice", i)
+		}
+		seen[i] = true
+		if result.GetName() != pairs[i].Name || result.GetLabels()["i"] != fmt.Sprint(i) {
+			t.Errorf("result %d has name %q and labels %v", i, result.GetName(), result.GetLabels())
+		}
+
+		switch {
+		case i%5 == 0:
+			if result.GetError() == "" || len(result.GetChanges()) > 0 {
+				t.Errorf("result %d = %v, want an error only", i, result)
+			}
+		case result.GetError() != "":
+			t.Errorf("result %d has unexpected error %q", i, result.GetError())
+		case len(result.GetChanges()) != 1 || result.GetChanges()[0].Left.GetNumberValue() != float64(i):
+			t.Errorf("result %d has changes %v, want .n changed from %d", i, result.GetChanges(), i)
+		}
+	}
+	if len(seen) != len(pairs) {
+		t.Errorf("got %d results, want %d", len(seen), len(pairs))
+	}
+}
+
+func TestGRPCCompareBatchCancel(t *testing.T) {
+	client := testDiffClient(t, &config{})
+
+	pairs := make([]*pb.BatchPair, 1000)
+	for i := range pairs {
+		pairs[i] = &pb.BatchPair{Request: &pb.CompareRequest{Left: "a: 1\n", Right: "a: 2\n"}}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := client.CompareBatch(ctx, &pb.CompareBatchRequest{Pairs: pairs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	for {
+		if _, err = stream.Recv(); err != nil {
+			break
+		}
+	}
+	if status.Code(err) != codes.Canceled {
+		t.Errorf("error after cancellation = %v, want Canceled", err)
+	}
+}
+
+// batchStream is a server side stream of batch results that cancels its
+// context after a number of results
+type batchStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	cancel context.CancelFunc
+	after  int
+	sent   int
+}
+
+func (s *batchStream) Context() context.Context { return s.ctx }
+
+func (s *batchStream) Send(*pb.BatchResult) error {
+	s.sent++
+	if s.sent == s.after {
+		s.cancel()
+	}
+	return nil
+}
+
+func TestCompareBatchStopsWhenCancelled(t *testing.T) {
+	pairs := make([]*pb.BatchPair, 1000)
+	for i := range pairs {
+		pairs[i] = &pb.BatchPair{Request: &pb.CompareRequest{Left: "a: 1\n", Right: "a: 2\n"}}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := &batchStream{ctx: ctx, cancel: cancel, after: 1}
+	err := (&diffServer{cfg: &config{}}).CompareBatch(&pb.CompareBatchRequest{Pairs: pairs}, stream)
+	if err != context.Canceled {
+		t.Errorf("CompareBatch() error = %v, want %v", err, context.Canceled)
+	}
+	if stream.sent == len(pairs) {
+		t.Errorf("all %d results were sent after cancellation", stream.sent)
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// segmentOp describes how a segment of an inline diff relates the two strings
+type segmentOp int
+
+const (
+	segmentEqual segmentOp = iota
+	segmentDelete
+	segmentInsert
+)
+
+// segment is a run of text that is equal, only in the first string or only
+// in the second string
+type segment struct {
+	op   segmentOp
+	text string
+}
+
+// maxInlineDiffCells bounds the size of the LCS table; larger inputs are
+// reported as a single replacement
+const maxInlineDiffCells = 1 << 20
+
+// elideContext is the number of runes kept on each side of an equal segment
+// when the inline diff has to be shortened to fit the terminal
+const elideContext = 10
+
+// tokenize splits s into the units the inline diff works on. In "char" mode
+// every rune is a token, otherwise runs of letters and digits form words and
+// every other rune is a token of its own.
+func tokenize(s, mode string) []string {
+	var tokens []string
+	if mode == "char" {
+		for _, r := range s {
+			tokens = append(tokens, string(r))
+		}
+		return tokens
+	}
+
+	start := -1
+	for i, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			tokens = append(tokens, s[start:i])
+			start = -1
+		}
+		tokens = append(tokens, string(r))
+	}
+	if start >= 0 {
+		tokens = append(tokens, s[start:])
+	}
+	return tokens
+}
+
+// inlineDiff computes the word or character level difference between two
+// strings as a list of segments, merging adjacent tokens with the same op
+func inlineDiff(s1, s2, mode string) []segment {
+	a, b := tokenize(s1, mode), tokenize(s2, mode)
+	if len(a)*len(b) > maxInlineDiffCells {
+		return []segment{{segmentDelete, s1}, {segmentInsert, s2}}
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var segments []segment
+	add := func(op segmentOp, text string) {
+		if n := len(segments); n > 0 && segments[n-1].op == op {
+			segments[n-1].text += text
+			return
+		}
+		segments = append(segments, segment{op, text})
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			add(segmentEqual, a[i])
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			add(segmentDelete, a[i])
+			i++
+		default:
+			add(segmentInsert, b[j])
+			j++
+		}
+	}
+	return segments
+}
+
+// elideSegments shortens long equal segments to their first and last few
+// runes so the changed parts of long values remain visible
+func elideSegments(segments []segment) []segment {
+	elided := make([]segment, len(segments))
+	for i, seg := range segments {
+		runes := []rune(seg.text)
+		if seg.op == segmentEqual && len(runes) > 2*elideContext+1 {
+			head, tail := string(runes[:elideContext]), string(runes[len(runes)-elideContext:])
+			switch {
+			case i == 0:
+				seg.text = "…" + tail
+			case i == len(segments)-1:
+				seg.text = head + "…"
+			default:
+				seg.text = head + "…" + tail
+			}
+		}
+		elided[i] = seg
+	}
+	return elided
+}
+
+// printInlineDiff prints the word or character level difference between two
+// strings, marking removed text as [-text-] and added text as {+text+}. In
+// accessible mode the markers are spelled out as words.
+func (p *printer) printInlineDiff(prefix, s1, s2 string) {
+	segments := inlineDiff(s1, s2, p.inlineDiff)
+	if p.showWhitespace {
+		segments = visualizeSegments(segments, s1, s2)
+	}
+	line := p.renderSegments(segments)
+	if p.width > 0 && visibleWidth(prefix+line) > p.width {
+		line = p.renderSegments(elideSegments(segments))
+	}
+
+	lines := strings.Split(line, "\n")
+	fmt.Fprintln(p.w, p.truncate(prefix+lines[0]))
+	for _, l := range lines[1:] {
+		fmt.Fprintln(p.w, p.truncate("    "+l))
+	}
+}
+
+// visualizeSegments makes whitespace and invisible characters of inline diff
+// segments visible the way they appear in the whole strings s1 and s2. Text
+// common to both strings shows a trailing space when it is trailing in either
+// of them.
+func visualizeSegments(segments []segment, s1, s2 string) []segment {
+	symbols1, symbols2 := visibleRunes(s1), visibleRunes(s2)
+	i1, i2 := 0, 0
+	visualized := make([]segment, len(segments))
+	for i, seg := range segments {
+		var b strings.Builder
+		for range seg.text {
+			switch seg.op {
+			case segmentEqual:
+				symbol := symbols1[i1]
+				if symbols2[i2] == "·" {
+					symbol = symbols2[i2]
+				}
+				b.WriteString(symbol)
+				i1++
+				i2++
+			case segmentDelete:
+				b.WriteString(symbols1[i1])
+				i1++
+			case segmentInsert:
+				b.WriteString(symbols2[i2])
+				i2++
+			}
+		}
+		visualized[i] = segment{seg.op, b.String()}
+	}
+	return visualized
+}
+
+// renderSegments renders inline diff segments with removal and insertion
+// markers
+func (p *printer) renderSegments(segments []segment) string {
+	var b strings.Builder
+	for _, seg := range segments {
+		switch seg.op {
+		case segmentEqual:
+			b.WriteString(seg.text)
+		case segmentDelete:
+			if p.accessible {
+				fmt.Fprintf(&b, "[%s %s]", p.msg.Removed, seg.text)
+			} else {
+				fmt.Fprintf(&b, "[-%s-]", seg.text)
+			}
+		case segmentInsert:
+			if p.accessible {
+				fmt.Fprintf(&b, "[%s %s]", p.msg.Added, seg.text)
+			} else {
+				fmt.Fprintf(&b, "{+%s+}", seg.text)
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestInlineDiff(t *testing.T) {
+	tests := []struct {
+		name   string
+		s1, s2 string
+		mode   string
+		want   []segment
+	}{
+		{"equal", "abc", "abc", "word", []segment{{segmentEqual, "abc"}}},
+		{"word replaced", "http://a/b/x", "http://a/c/x", "word", []segment{
+			{segmentEqual, "http://a/"}, {segmentDelete, "b"}, {segmentInsert, "c"}, {segmentEqual, "/x"},
+		}},
+		{"whole words", "version one", "version two", "word", []segment{
+			{segmentEqual, "version "}, {segmentDelete, "one"}, {segmentInsert, "two"},
+		}},
+		{"characters", "color", "colour", "char", []segment{
+			{segmentEqual, "colo"}, {segmentInsert, "u"}, {segmentEqual, "r"},
+		}},
+		{"from empty", "", "new", "word", []segment{{segmentInsert, "new"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inlineDiff(tt.s1, tt.s2, tt.mode); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("inlineDiff(%q, %q) = %v, want %v", tt.s1, tt.s2, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintInlineDiff(t *testing.T) {
+	tests := []struct {
+		name           string
+		s1, s2         string
+		mode           string
+		showWhitespace bool
+		want           string
+	}{
+		{"markers", "http://a/b/x", "http://a/c/x", "word", false, "http://a/[-b-]{+c+}/x\n"},
+		{"inner space stays a space", "http://a b/x", "http://a c/x", "word", true, "http://a [-b-]{+c+}/x\n"},
+		{"space trailing in the second string", "a b", "a ", "char", true, "a·[-b-]\n"},
+		{"changed tab", "a\tb", "a b", "char", true, "a[-→-]{+ +}b\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			p := &printer{w: &buf, msg: catalogFor("en"), inlineDiff: tt.mode, showWhitespace: tt.showWhitespace}
+			p.printInlineDiff("", tt.s1, tt.s2)
+			if got := buf.String(); got != tt.want {
+				t.Errorf("printInlineDiff(%q, %q) = %q, want %q", tt.s1, tt.s2, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestElideSegments(t *testing.T) {
+	long := "abcdefghijklmnopqrstuvwxyz"
+	got := elideSegments([]segment{{segmentEqual, long}, {segmentDelete, "1"}, {segmentEqual, long}})
+	want := []segment{{segmentEqual, "…qrstuvwxyz"}, {segmentDelete, "1"}, {segmentEqual, "abcdefghij…"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("elideSegments() = %v, want %v", got, want)
+	}
+}
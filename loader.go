@@ -0,0 +1,17 @@
+package main
+
+import (
+	"io/ioutil"
+
+	"yamldiff/compare"
+)
+
+// loadSource loads a YAML file as a document
+func loadSource(filePath string, matchers bool) (*compare.Document, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return compare.Parse(data, matchers)
+}
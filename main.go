@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// withDefaultCommand prepends the diff command to the arguments unless they
+// name another command or ask for help, so "yamldiff a.yaml b.yaml" keeps
+// working. Shell completion requests are handled the same way once the
+// command name has been completed.
+func withDefaultCommand(root *cobra.Command, args []string) []string {
+	rest := args
+	if len(args) > 0 && args[0] == cobra.ShellCompRequestCmd {
+		rest = args[1:]
+		if len(rest) <= 1 {
+			return args
+		}
+	}
+	if len(rest) == 0 {
+		return args
+	}
+	switch rest[0] {
+	case "-h", "--help", "--version":
+		return args
+	}
+	if cmd, _, err := root.Find(rest); err == nil && cmd != root {
+		return args
+	}
+
+	prefix := args[:len(args)-len(rest)]
+	return append(append(append([]string{}, prefix...), "diff"), rest...)
+}
+
+func main() {
+	rootCmd := &cobra.Command{
+		Use:   "yamldiff",
+		Short: "Compare YAML files semantically.",
+		Long: `yamldiff compares YAML files by their content instead of their text, so
+reordered keys and reformatting are no differences. Without a command it
+runs diff: "yamldiff a.yaml b.yaml" compares two files.
+
+The flags --color, --config, --verbose and --debug apply to every command.
+With -v the time taken by each phase (load, normalize, compare, render) is
+printed to stderr, with -vv or --debug also why each path was reported,
+skipped or found equal, and which values are read differently than they are
+written, like yes read as true or keys copied by a merge key.`,
+		Version: version,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			if globalFlags.debug && globalFlags.verbosity < 2 {
+				globalFlags.verbosity = 2
+			}
+			_, err := useColor(globalFlags.color)
+			return err
+		},
+	}
+
+	rootCmd.PersistentFlags().StringVar(&globalFlags.color, "color", "auto", "Color the output (auto, always, never).")
+	rootCmd.PersistentFlags().StringVar(&globalFlags.config, "config", "", "Configuration file with profiles (default .yamldiff.yaml).")
+	rootCmd.PersistentFlags().CountVarP(&globalFlags.verbosity, "verbose", "v", "Print more details about what is done, repeat for more.")
+	rootCmd.PersistentFlags().BoolVar(&globalFlags.debug, "debug", false, "Explain every decision of the comparison, like -vv.")
+	rootCmd.RegisterFlagCompletionFunc("color", cobra.FixedCompletions([]string{"auto", "always", "never"}, cobra.ShellCompDirectiveNoFileComp))
+
+	rootCmd.AddCommand(newDiffCmd())
+	rootCmd.AddCommand(newApplyCmd())
+	rootCmd.AddCommand(newMergeCmd())
+	rootCmd.AddCommand(newMergeDriverCmd())
+	rootCmd.AddCommand(newTextconvCmd())
+	rootCmd.AddCommand(newPRCmd())
+	rootCmd.AddCommand(newRenderCmd())
+	rootCmd.AddCommand(newCompareResultsCmd())
+	rootCmd.AddCommand(newExpectCmd())
+	rootCmd.AddCommand(newValidateCmd())
+	rootCmd.AddCommand(newFmtCmd())
+	rootCmd.AddCommand(newBatchCmd())
+	rootCmd.AddCommand(newServeCmd())
+	rootCmd.AddCommand(newVersionCmd())
+
+	// Execute the root command, running diff unless another command is named
+	rootCmd.InitDefaultHelpCmd()
+	rootCmd.InitDefaultCompletionCmd()
+	rootCmd.SetArgs(withDefaultCommand(rootCmd, os.Args[1:]))
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestWithDefaultCommand(t *testing.T) {
+	root := &cobra.Command{Use: "yamldiff"}
+	root.AddCommand(&cobra.Command{Use: "diff", Run: func(*cobra.Command, []string) {}})
+	root.AddCommand(&cobra.Command{Use: "fmt", Run: func(*cobra.Command, []string) {}})
+	root.InitDefaultHelpCmd()
+	root.InitDefaultCompletionCmd()
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{nil, nil},
+		{[]string{"a.yaml", "b.yaml"}, []string{"diff", "a.yaml", "b.yaml"}},
+		{[]string{"--color", "never", "a.yaml", "b.yaml"}, []string{"diff", "--color", "never", "a.yaml", "b.yaml"}},
+		{[]string{"diff", "a.yaml", "b.yaml"}, []string{"diff", "a.yaml", "b.yaml"}},
+		{[]string{"fmt", "-w", "a.yaml"}, []string{"fmt", "-w", "a.yaml"}},
+		{[]string{"help", "diff"}, []string{"help", "diff"}},
+		{[]string{"--help"}, []string{"--help"}},
+		{[]string{"--version"}, []string{"--version"}},
+		{[]string{cobra.ShellCompRequestCmd, "a"}, []string{cobra.ShellCompRequestCmd, "a"}},
+		{[]string{cobra.ShellCompRequestCmd, "a.yaml", ""}, []string{cobra.ShellCompRequestCmd, "diff", "a.yaml", ""}},
+		{[]string{cobra.ShellCompRequestCmd, "fmt", ""}, []string{cobra.ShellCompRequestCmd, "fmt", ""}},
+	}
+
+	for _, tt := range tests {
+		if got := withDefaultCommand(root, tt.args); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("withDefaultCommand(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
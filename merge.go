@@ -0,0 +1,500 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/cobra"
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"yamldiff/compare"
+)
+
+// defaultMergePatterns are the .gitattributes patterns registered by
+// merge-driver setup when none are given
+var defaultMergePatterns = []string{"*.yaml", "*.yml"}
+
+// mergeDocument is one version of a file taking part in a merge
+type mergeDocument struct {
+	data []byte
+	// doc is the single document of the file, nil for an empty file
+	doc *yamlv3.Node
+}
+
+// loadMergeDocument loads a version of a file for a structural merge. It
+// fails unless the file is empty or holds a single document whose top level
+// is a mapping without anchors or aliases, as merging anything else could
+// lose content.
+func loadMergeDocument(filePath string) (*mergeDocument, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	docs, err := compare.DecodeDocuments(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case len(docs) == 0 && strings.TrimSpace(string(data)) == "":
+		return &mergeDocument{data: data}, nil
+	case len(docs) == 0:
+		return nil, fmt.Errorf("no document besides comments")
+	case len(docs) > 1:
+		return nil, fmt.Errorf("%d documents instead of one", len(docs))
+	case len(docs[0].Content) == 0 || docs[0].Content[0].Kind != yamlv3.MappingNode:
+		return nil, fmt.Errorf("top level is not a mapping")
+	case usesAliases(docs[0]):
+		return nil, fmt.Errorf("anchors or aliases are used")
+	}
+	return &mergeDocument{data: data, doc: docs[0]}, nil
+}
+
+// root returns the top level mapping of a document, nil for an empty file
+func (d *mergeDocument) root() *yamlv3.Node {
+	if d.doc == nil {
+		return nil
+	}
+	return d.doc.Content[0]
+}
+
+// usesAliases reports whether a node tree contains anchors, aliases or merge
+// keys
+func usesAliases(node *yamlv3.Node) bool {
+	if node.Kind == yamlv3.AliasNode || node.Anchor != "" || node.Tag == "!!merge" {
+		return true
+	}
+	for _, child := range node.Content {
+		if usesAliases(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeNodes performs a three-way merge of a value. Mappings are merged key by
+// key; any other value is taken from the side that changed it, with its
+// comments and formatting. When both sides changed a value differently the
+// path is added to conflicts and our value is kept. A nil node is a missing
+// key.
+func mergeNodes(base, ours, theirs *yamlv3.Node, path string, conflicts *[]string) *yamlv3.Node {
+	if ours != nil && theirs != nil && ours.Kind == yamlv3.MappingNode && theirs.Kind == yamlv3.MappingNode {
+		if base != nil && base.Kind != yamlv3.MappingNode {
+			base = nil
+		}
+		return mergeMappingNodes(base, ours, theirs, path, conflicts)
+	}
+
+	switch {
+	case sameNode(ours, theirs):
+		return ours
+	case sameNode(base, ours):
+		return theirs
+	case sameNode(base, theirs):
+		return ours
+	}
+
+	*conflicts = append(*conflicts, path)
+	return ours
+}
+
+// mergeMappingNodes merges the keys of two mappings against their common
+// base. Keys keep our order, followed by keys only added on their side.
+func mergeMappingNodes(base, ours, theirs *yamlv3.Node, path string, conflicts *[]string) *yamlv3.Node {
+	merged := *ours
+	merged.Content = nil
+
+	for i := 0; i+1 < len(ours.Content); i += 2 {
+		key := ours.Content[i]
+		keyPath := compare.JoinPath(path, key.Value)
+		if key.Kind != yamlv3.ScalarNode {
+			*conflicts = append(*conflicts, keyPath)
+			merged.Content = append(merged.Content, key, ours.Content[i+1])
+			continue
+		}
+		val := mergeNodes(mappingValue(base, key), ours.Content[i+1], mappingValue(theirs, key), keyPath, conflicts)
+		if val != nil {
+			merged.Content = append(merged.Content, key, val)
+		}
+	}
+
+	for i := 0; i+1 < len(theirs.Content); i += 2 {
+		key := theirs.Content[i]
+		if mappingValue(ours, key) != nil {
+			continue
+		}
+		keyPath := compare.JoinPath(path, key.Value)
+		if val := mergeNodes(mappingValue(base, key), nil, theirs.Content[i+1], keyPath, conflicts); val != nil {
+			merged.Content = append(merged.Content, key, val)
+		}
+	}
+	return &merged
+}
+
+// mappingValue returns the value stored under a scalar key of a mapping, or
+// nil
+func mappingValue(mapping, key *yamlv3.Node) *yamlv3.Node {
+	if mapping == nil {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		k := mapping.Content[i]
+		if k.Kind == yamlv3.ScalarNode && k.Value == key.Value && k.ShortTag() == key.ShortTag() {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// sameNode reports whether two nodes hold equal values, ignoring the order
+// of keys, comments and formatting
+func sameNode(a, b *yamlv3.Node) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	valA, errA := compare.NodeValue(a, false)
+	valB, errB := compare.NodeValue(b, false)
+	return errA == nil && errB == nil && reflect.DeepEqual(valA, valB)
+}
+
+// indentOf returns the indentation of the first indented line of a YAML
+// file, or 2
+func indentOf(data []byte) int {
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if n := len(line) - len(trimmed); n > 0 && trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			return n
+		}
+	}
+	return 2
+}
+
+// mergeDocuments merges three versions of a file and returns the content of
+// the merged file, or the conflicting paths. The result is verified to
+// decode to the merged values, so no content is lost in writing it.
+func mergeDocuments(base, ours, theirs *mergeDocument) ([]byte, []string, error) {
+	switch {
+	case sameNode(base.root(), theirs.root()):
+		return ours.data, nil, nil
+	case sameNode(base.root(), ours.root()):
+		return theirs.data, nil, nil
+	}
+
+	var conflicts []string
+	empty := &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+	oursRoot, theirsRoot := ours.root(), theirs.root()
+	if oursRoot == nil {
+		oursRoot = empty
+	}
+	if theirsRoot == nil {
+		theirsRoot = empty
+	}
+	merged := mergeNodes(base.root(), oursRoot, theirsRoot, "", &conflicts)
+	if len(conflicts) > 0 {
+		return nil, conflicts, nil
+	}
+
+	doc := &yamlv3.Node{Kind: yamlv3.DocumentNode, Content: []*yamlv3.Node{merged}}
+	if ours.doc != nil {
+		doc.HeadComment, doc.LineComment, doc.FootComment = ours.doc.HeadComment, ours.doc.LineComment, ours.doc.FootComment
+	}
+
+	var buf bytes.Buffer
+	enc := yamlv3.NewEncoder(&buf)
+	enc.SetIndent(indentOf(ours.data))
+	if err := enc.Encode(doc); err != nil {
+		return nil, nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, nil, err
+	}
+
+	var written yamlv3.Node
+	if err := yamlv3.Unmarshal(buf.Bytes(), &written); err != nil || len(written.Content) == 0 || !sameNode(written.Content[0], merged) {
+		return nil, nil, fmt.Errorf("the merged file does not read back as the merged values")
+	}
+	return buf.Bytes(), nil, nil
+}
+
+// mergeTextually merges three versions of a file line by line with git
+// merge-file and returns the result, with conflict markers where the changes
+// overlap, and the number of conflicts
+func mergeTextually(basePath, currentPath, otherPath string) ([]byte, int, error) {
+	textual := exec.Command("git", "merge-file", "-p", "-L", "current", "-L", "base", "-L", "other",
+		currentPath, basePath, otherPath)
+	textual.Stderr = os.Stderr
+	merged, err := textual.Output()
+
+	// The exit status is the number of conflicts, up to 127, and negative on
+	// errors
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() > 0 && exitErr.ExitCode() <= 127 {
+		return merged, exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("git merge-file: %v", err)
+	}
+	return merged, 0, nil
+}
+
+// mergeFiles merges three versions of a file named name. Files are merged key
+// by key; files that cannot be merged structurally and conflicting changes
+// fall back to git's textual merge, with the reason printed to stderr. It
+// returns the merged content and whether conflicts remain.
+func mergeFiles(basePath, currentPath, otherPath, name string) ([]byte, bool, error) {
+	// Files that cannot be merged structurally are merged as text, which may
+	// well be clean
+	fallback := func(format string, args ...interface{}) ([]byte, bool, error) {
+		fmt.Fprintf(os.Stderr, "yamldiff: "+format+", falling back to a textual merge of %s\n", append(args, name)...)
+		merged, conflicts, err := mergeTextually(basePath, currentPath, otherPath)
+		return merged, conflicts > 0, err
+	}
+
+	var docs [3]*mergeDocument
+	for i, version := range []struct{ label, path string }{{"base", basePath}, {"current", currentPath}, {"other", otherPath}} {
+		doc, err := loadMergeDocument(version.path)
+		if err != nil {
+			return fallback("cannot merge the %s version structurally: %v", version.label, err)
+		}
+		docs[i] = doc
+	}
+
+	merged, conflicts, err := mergeDocuments(docs[0], docs[1], docs[2])
+	if err != nil {
+		return fallback("%v", err)
+	}
+	if len(conflicts) > 0 {
+		fmt.Fprintf(os.Stderr, "yamldiff: conflicting changes in %s:\n", name)
+		for _, path := range conflicts {
+			fmt.Fprintf(os.Stderr, "  %s\n", path)
+		}
+
+		// Keep git's conflict markers for resolving the changes by hand, the
+		// conflicts remain even when the lines happen to merge cleanly
+		merged, _, err := mergeTextually(basePath, currentPath, otherPath)
+		return merged, true, err
+	}
+	return merged, false, nil
+}
+
+// newMergeDriverCmd returns the merge-driver command implementing git's merge
+// driver contract: the merge result is written to the current file and the
+// exit status is non-zero when conflicts remain.
+func newMergeDriverCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "merge-driver base current other [path]",
+		Short: "Merge YAML files structurally as a git merge driver.",
+		Long: `merge-driver performs a three-way merge of YAML files key by key, so changes
+to different keys never conflict regardless of how the text lines up.
+It follows git's merge driver contract: git passes the common ancestor (%O),
+the current version (%A), the other branch's version (%B) and the path of the
+merged file (%P), and the result is written to the current version.
+
+Comments and formatting of the values are kept. When both branches changed
+the same value differently, the conflicting paths are reported, the file gets
+git's textual merge so the usual conflict markers can be resolved by hand and
+the command exits non-zero. Files that are not a single document with a
+mapping at the top level, such as lists or multi-document files, and files
+using anchors or aliases are merged textually like git does without a driver,
+exiting non-zero only when that leaves conflicts.
+
+Register the driver with "yamldiff merge-driver setup". To merge files by hand
+without overwriting any of them, use "yamldiff merge".`,
+		Args: cobra.RangeArgs(3, 4),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			basePath, currentPath, otherPath := args[0], args[1], args[2]
+			name := currentPath
+			if len(args) == 4 {
+				name = args[3]
+			}
+
+			merged, conflicted, err := mergeFiles(basePath, currentPath, otherPath, name)
+			if err != nil {
+				return err
+			}
+			if err := ioutil.WriteFile(currentPath, merged, 0644); err != nil {
+				return err
+			}
+			if conflicted {
+				os.Exit(1)
+			}
+			return nil
+		},
+	}
+
+	cmd.AddCommand(newMergeDriverSetupCmd())
+	return cmd
+}
+
+// newMergeCmd returns the merge command, which merges three versions of a
+// file like the merge driver but prints the result instead of overwriting
+// one of its inputs
+func newMergeCmd() *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "merge base.yaml ours.yaml theirs.yaml",
+		Short: "Merge two versions of a YAML file changed from a common base.",
+		Long: `merge performs a three-way merge of YAML files key by key: the changes between
+base.yaml and ours.yaml and those between base.yaml and theirs.yaml are both
+kept, so changes to different keys never conflict regardless of how the text
+lines up:
+
+    yamldiff merge upstream-1.0.yaml local.yaml upstream-1.1.yaml -o merged.yaml
+
+The merged file is printed, or written to the file given with --output; the
+input files are never changed. Comments and formatting of the values are kept.
+When both sides changed the same value differently, the conflicting paths are
+reported, the result holds git's conflict markers and the command exits 1.
+Files that cannot be merged key by key, such as lists or multi-document files,
+are merged line by line like git merge-file does.`,
+		Args: cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			merged, conflicted, err := mergeFiles(args[0], args[1], args[2], args[1])
+			if err != nil {
+				return err
+			}
+			if output != "" {
+				err = ioutil.WriteFile(output, merged, 0644)
+			} else {
+				_, err = cmd.OutOrStdout().Write(merged)
+			}
+			if err != nil {
+				return err
+			}
+			if conflicted {
+				os.Exit(1)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Write the merged file to this path instead of stdout.")
+	return cmd
+}
+
+// newMergeDriverSetupCmd returns the command registering yamldiff as a merge
+// driver in the git configuration and .gitattributes
+func newMergeDriverSetupCmd() *cobra.Command {
+	var global bool
+
+	cmd := &cobra.Command{
+		Use:   "setup [pattern...]",
+		Short: "Configure git to merge YAML files with yamldiff.",
+		Long: `setup defines the "yamldiff" merge driver in the git configuration and assigns
+it to the given patterns (default *.yaml and *.yml) in the .gitattributes file
+at the root of the repository. With --global the driver is defined in the
+global git configuration and the patterns are added to the global attributes
+file (core.attributesFile, or ~/.config/git/attributes).`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			patterns := args
+			if len(patterns) == 0 {
+				patterns = defaultMergePatterns
+			}
+
+			scope := "--local"
+			if global {
+				scope = "--global"
+			}
+			settings := [][2]string{
+				{"merge.yamldiff.name", "yamldiff structural YAML merge"},
+				{"merge.yamldiff.driver", "yamldiff merge-driver %O %A %B %P"},
+			}
+			for _, setting := range settings {
+				if _, err := gitOutput("config", scope, setting[0], setting[1]); err != nil {
+					return err
+				}
+			}
+
+			attributesPath, err := gitAttributesPath(global)
+			if err != nil {
+				return err
+			}
+			if err := addGitAttributes(attributesPath, patterns); err != nil {
+				return err
+			}
+
+			fmt.Printf("Configured yamldiff merge driver for %s in %s\n", strings.Join(patterns, ", "), attributesPath)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&global, "global", false, "Configure the driver for all repositories of the current user.")
+	return cmd
+}
+
+// gitAttributesPath returns the attributes file the merge driver is
+// registered in
+func gitAttributesPath(global bool) (string, error) {
+	if !global {
+		root, err := gitOutput("rev-parse", "--show-toplevel")
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(root, ".gitattributes"), nil
+	}
+
+	// core.attributesFile is unset in most setups, so ignore the error
+	if path, _ := gitOutput("config", "--global", "core.attributesFile"); path != "" {
+		if strings.HasPrefix(path, "~/") {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+			path = filepath.Join(home, path[2:])
+		}
+		return path, nil
+	}
+
+	configDir := os.Getenv("XDG_CONFIG_HOME")
+	if configDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		configDir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configDir, "git", "attributes"), nil
+}
+
+// addGitAttributes appends a merge=yamldiff line for each pattern that does
+// not have one yet
+func addGitAttributes(path string, patterns []string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	content := string(data)
+	existing := make(map[string]bool)
+	for _, line := range strings.Split(content, "\n") {
+		existing[strings.TrimSpace(line)] = true
+	}
+
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	for _, pattern := range patterns {
+		line := pattern + " merge=yamldiff"
+		if !existing[line] {
+			content += line + "\n"
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, []byte(content), 0644)
+}
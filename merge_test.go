@@ -0,0 +1,275 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeTemp writes content to a file in a temporary directory and returns its
+// path
+func writeTemp(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadMergeDocument(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"mapping", "a: 1\n", ""},
+		{"empty", "", ""},
+		{"only comments", "# nothing\n", "no document"},
+		{"list", "- hosts: web\n", "not a mapping"},
+		{"scalar", "text\n", "not a mapping"},
+		{"several documents", "a: 1\n---\nb: 2\n", "2 documents"},
+		{"anchors", "a: &x 1\nb: *x\n", "anchors or aliases"},
+		{"merge keys", "a: {x: 1}\nb:\n  <<: {x: 2}\n", "anchors or aliases"},
+		{"invalid", "a: [\n", "did not find"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadMergeDocument(writeTemp(t, "f.yaml", tt.content))
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMergeDocuments(t *testing.T) {
+	tests := []struct {
+		name               string
+		base, ours, theirs string
+		want               string
+		wantConflicts      []string
+	}{
+		{
+			name:   "changes to different keys keep comments and quoting",
+			base:   "# config\nname: \"svc\" # quoted\nreplicas: 2\nenv:\n    LOG: info\n",
+			ours:   "# config\nname: \"svc\" # quoted\nreplicas: 2\nenv:\n    LOG: debug\n",
+			theirs: "# config\nname: \"svc\" # quoted\nreplicas: 3\nenv:\n    LOG: info\n",
+			want:   "# config\nname: \"svc\" # quoted\nreplicas: 3\nenv:\n    LOG: debug\n",
+		},
+		{
+			name:   "only their side changed keeps their file as is",
+			base:   "a: 1\n",
+			ours:   "a:   1\n",
+			theirs: "a:  2 # two\n",
+			want:   "a:  2 # two\n",
+		},
+		{
+			name:   "only our side changed keeps our file as is",
+			base:   "a: 1\n",
+			ours:   "a:    2\n",
+			theirs: "a: 1 # same\n",
+			want:   "a:    2\n",
+		},
+		{
+			name:   "keys added on both sides",
+			base:   "a: 1\n",
+			ours:   "a: 1\nb: 2\n",
+			theirs: "a: 1\nc: 3\n",
+			want:   "a: 1\nb: 2\nc: 3\n",
+		},
+		{
+			name:   "key removed on one side",
+			base:   "a: 1\nb: 2\n",
+			ours:   "a: 1\n",
+			theirs: "a: 1\nb: 2\nc: 3\n",
+			want:   "a: 1\nc: 3\n",
+		},
+		{
+			name:   "same change on both sides",
+			base:   "a: 1\nb: 1\n",
+			ours:   "a: 2\nb: 1\n",
+			theirs: "a: 2\nb: 3\n",
+			want:   "a: 2\nb: 3\n",
+		},
+		{
+			name:   "file added on both sides",
+			base:   "",
+			ours:   "a: 1\n",
+			theirs: "b: 2\n",
+			want:   "a: 1\nb: 2\n",
+		},
+		{
+			name:          "conflicting changes",
+			base:          "a: 1\nb: {c: 1}\n",
+			ours:          "a: 2\nb: {c: 2}\n",
+			theirs:        "a: 3\nb: {c: 3}\n",
+			wantConflicts: []string{".a", ".b.c"},
+		},
+		{
+			name:          "removed on one side and changed on the other",
+			base:          "a: 1\nb: 1\n",
+			ours:          "b: 1\n",
+			theirs:        "a: 2\nb: 1\n",
+			wantConflicts: []string{".a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var docs [3]*mergeDocument
+			for i, content := range []string{tt.base, tt.ours, tt.theirs} {
+				doc, err := loadMergeDocument(writeTemp(t, "f.yaml", content))
+				if err != nil {
+					t.Fatal(err)
+				}
+				docs[i] = doc
+			}
+
+			merged, conflicts, err := mergeDocuments(docs[0], docs[1], docs[2])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(conflicts, tt.wantConflicts) {
+				t.Errorf("conflicts = %v, want %v", conflicts, tt.wantConflicts)
+			}
+			if tt.wantConflicts == nil && string(merged) != tt.want {
+				t.Errorf("merged =\n%s\nwant\n%s", merged, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	tests := []struct {
+		name               string
+		base, ours, theirs string
+		want               string
+		wantConflicts      bool
+	}{
+		{
+			name:   "structural",
+			base:   "a: 1\nb: 1\n",
+			ours:   "b: 1\na: 2\n",
+			theirs: "a: 1\nb: 2\n",
+			want:   "b: 2\na: 2\n",
+		},
+		{
+			name:   "clean textual fallback for several documents",
+			base:   "a: 1\n---\nb: 1\n",
+			ours:   "a: 2\n---\nb: 1\n",
+			theirs: "a: 1\n---\nb: 2\n",
+			want:   "a: 2\n---\nb: 2\n",
+		},
+		{
+			name:          "conflicting textual fallback for a list",
+			base:          "- a\n",
+			ours:          "- b\n",
+			theirs:        "- c\n",
+			want:          "<<<<<<< current\n- b\n=======\n- c\n>>>>>>> other\n",
+			wantConflicts: true,
+		},
+		{
+			name:          "structural conflict",
+			base:          "a: 1\n",
+			ours:          "a: 2\n",
+			theirs:        "a: 3\n",
+			want:          "<<<<<<< current\na: 2\n=======\na: 3\n>>>>>>> other\n",
+			wantConflicts: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, ours, theirs := writeTemp(t, "base.yaml", tt.base), writeTemp(t, "ours.yaml", tt.ours), writeTemp(t, "theirs.yaml", tt.theirs)
+			merged, conflicted, err := mergeFiles(base, ours, theirs, "f.yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(merged) != tt.want || conflicted != tt.wantConflicts {
+				t.Errorf("mergeFiles() = %q, %v, want %q, %v", merged, conflicted, tt.want, tt.wantConflicts)
+			}
+		})
+	}
+}
+
+func TestMergeDriverCleanFallback(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	base := writeTemp(t, "base.yaml", "- a\n- b\n- c\n")
+	current := writeTemp(t, "current.yaml", "- a\n- b\n- c\n- d\n")
+	other := writeTemp(t, "other.yaml", "- z\n- a\n- b\n- c\n")
+
+	cmd := newMergeDriverCmd()
+	cmd.SetArgs([]string{base, current, other, "list.yaml"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "- z\n- a\n- b\n- c\n- d\n"; string(data) != want {
+		t.Errorf("merged file = %q, want %q", data, want)
+	}
+}
+
+func TestMergeCmd(t *testing.T) {
+	base := writeTemp(t, "base.yaml", "a: 1\nb: 1\n")
+	ours := writeTemp(t, "ours.yaml", "a: 2 # ours\nb: 1\n")
+	theirs := writeTemp(t, "theirs.yaml", "a: 1\nb: 3\n")
+	output := filepath.Join(t.TempDir(), "merged.yaml")
+
+	var stdout strings.Builder
+	cmd := newMergeCmd()
+	cmd.SetOut(&stdout)
+	cmd.SetArgs([]string{base, ours, theirs})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	want := "a: 2 # ours\nb: 3\n"
+	if stdout.String() != want {
+		t.Errorf("printed merge = %q, want %q", stdout.String(), want)
+	}
+
+	cmd = newMergeCmd()
+	cmd.SetArgs([]string{base, ours, theirs, "-o", output})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(output); err != nil || string(data) != want {
+		t.Errorf("written merge = %q (%v), want %q", data, err, want)
+	}
+
+	// The inputs are left as they are
+	if data, _ := os.ReadFile(ours); string(data) != "a: 2 # ours\nb: 1\n" {
+		t.Errorf("ours changed to %q", data)
+	}
+}
+
+func TestAddGitAttributes(t *testing.T) {
+	path := writeTemp(t, ".gitattributes", "*.json text\n*.yaml merge=yamldiff")
+	if err := addGitAttributes(path, []string{"*.yaml", "*.yml"}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "*.json text\n*.yaml merge=yamldiff\n*.yml merge=yamldiff\n"; string(data) != want {
+		t.Errorf("attributes = %q, want %q", data, want)
+	}
+}
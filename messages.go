@@ -0,0 +1,100 @@
+package main
+
+import (
+	"os"
+	"strings"
+)
+
+// messages holds the fixed strings printed in human-readable output
+type messages struct {
+	DifferenceAt string
+	FirstFile    string
+	SecondFile   string
+	DiffHeader   string
+	Changed      string
+	Added        string
+	Removed      string
+	// Before and After label the values of a changed value in accessible
+	// output
+	Before    string
+	After     string
+	DiffersAt string
+	Changes   string
+	// KeyAdded and KeyRemoved follow the path of a value found in only one
+	// of the files
+	KeyAdded   string
+	KeyRemoved string
+}
+
+// catalogs maps a language code to its message catalog
+var catalogs = map[string]messages{
+	"en": {
+		DifferenceAt: "Difference at:",
+		FirstFile:    "First file:",
+		SecondFile:   "Second file:",
+		DiffHeader:   "Differing Values from First File",
+		Changed:      "CHANGED:",
+		Added:        "ADDED:",
+		Removed:      "REMOVED:",
+		Before:       "BEFORE:",
+		After:        "AFTER:",
+		DiffersAt:    "DIFFERS AT CHARACTERS:",
+		Changes:      "Changes:",
+		KeyAdded:     "(added)",
+		KeyRemoved:   "(removed)",
+	},
+	"de": {
+		DifferenceAt: "Unterschied bei:",
+		FirstFile:    "Erste Datei:",
+		SecondFile:   "Zweite Datei:",
+		DiffHeader:   "Abweichende Werte aus der ersten Datei",
+		Changed:      "GEÄNDERT:",
+		Added:        "HINZUGEFÜGT:",
+		Removed:      "ENTFERNT:",
+		Before:       "VORHER:",
+		After:        "NACHHER:",
+		DiffersAt:    "UNTERSCHIED BEI ZEICHEN:",
+		Changes:      "Änderungen:",
+		KeyAdded:     "(hinzugefügt)",
+		KeyRemoved:   "(entfernt)",
+	},
+	"es": {
+		DifferenceAt: "Diferencia en:",
+		FirstFile:    "Primer archivo:",
+		SecondFile:   "Segundo archivo:",
+		DiffHeader:   "Valores distintos del primer archivo",
+		Changed:      "CAMBIADO:",
+		Added:        "AÑADIDO:",
+		Removed:      "ELIMINADO:",
+		Before:       "ANTES:",
+		After:        "DESPUÉS:",
+		DiffersAt:    "DIFERENCIA EN LOS CARACTERES:",
+		Changes:      "Cambios:",
+		KeyAdded:     "(añadido)",
+		KeyRemoved:   "(eliminado)",
+	},
+}
+
+// catalogFor returns the message catalog for lang, falling back to the
+// locale environment variables and finally to English
+func catalogFor(lang string) messages {
+	if lang == "" {
+		for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+			if value := os.Getenv(name); value != "" {
+				lang = value
+				break
+			}
+		}
+	}
+
+	// Strip territory and encoding, e.g. "de_DE.UTF-8" becomes "de"
+	lang = strings.ToLower(lang)
+	if i := strings.IndexAny(lang, "_.@-"); i >= 0 {
+		lang = lang[:i]
+	}
+
+	if msg, ok := catalogs[lang]; ok {
+		return msg
+	}
+	return catalogs["en"]
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"yamldiff/compare"
+)
+
+func TestCatalogFor(t *testing.T) {
+	tests := []struct {
+		lang, env, want string
+	}{
+		{"de", "", "Unterschied bei:"},
+		{"ES", "", "Diferencia en:"},
+		{"", "de_DE.UTF-8", "Unterschied bei:"},
+		{"", "es-MX", "Diferencia en:"},
+		{"fr", "de_DE.UTF-8", "Difference at:"},
+		{"", "", "Difference at:"},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("LC_ALL", "")
+		t.Setenv("LC_MESSAGES", "")
+		t.Setenv("LANG", tt.env)
+		if got := catalogFor(tt.lang).DifferenceAt; got != tt.want {
+			t.Errorf("catalogFor(%q) with LANG=%q = %q, want %q", tt.lang, tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestPrintDifferenceLocalized(t *testing.T) {
+	modified := compare.Change{Path: ".a", Kind: compare.Modified, Left: 1, Right: "x", LeftRange: &compare.Range{Line: 3}, RightRange: &compare.Range{Line: 4}}
+	added := compare.Change{Path: ".b", Kind: compare.Added, Right: []interface{}{1, 2}}
+
+	tests := []struct {
+		name       string
+		lang       string
+		accessible bool
+		ch         compare.Change
+		want       string
+	}{
+		{
+			name: "modified in Spanish",
+			lang: "es",
+			ch:   modified,
+			want: "\nDiferencia en: .a (a.yaml:3 → b.yaml:4)\n  Primer archivo:  1\n  Segundo archivo: x\n",
+		},
+		{
+			name:       "accessible modified in German",
+			lang:       "de",
+			accessible: true,
+			ch:         modified,
+			want:       "~ GEÄNDERT: .a (a.yaml:3 → b.yaml:4)\n- VORHER: Erste Datei: 1\n+ NACHHER: Zweite Datei: x\n",
+		},
+		{
+			name: "added collection in German",
+			lang: "de",
+			ch:   added,
+			want: "\nUnterschied bei: .b (hinzugefügt)\n  Zweite Datei:\n    - 1\n    - 2\n",
+		},
+		{
+			name:       "accessible added in Spanish",
+			lang:       "es",
+			accessible: true,
+			ch:         added,
+			want:       "+ AÑADIDO: .b\n+ AÑADIDO: Segundo archivo:\n+ AÑADIDO: Segundo archivo:   - 1\n+ AÑADIDO: Segundo archivo:   - 2\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			p := &printer{w: &buf, msg: catalogFor(tt.lang), accessible: tt.accessible, leftName: "a.yaml", rightName: "b.yaml"}
+			p.printDifference(tt.ch)
+			if buf.String() != tt.want {
+				t.Errorf("output =\n%q\nwant\n%q", buf.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+
+	"golang.org/x/term"
+)
+
+// defaultPager is used when the PAGER environment variable is not set
+const defaultPager = "less -R"
+
+// terminalSize returns the width and height of the terminal attached to
+// stdout. ok is false when stdout is not a terminal.
+func terminalSize() (width, height int, ok bool) {
+	fd := int(os.Stdout.Fd())
+	if !term.IsTerminal(fd) {
+		return 0, 0, false
+	}
+
+	width, height, err := term.GetSize(fd)
+	if err != nil {
+		return 0, 0, false
+	}
+	return width, height, true
+}
+
+// writeOutput writes the buffered output to stdout. Like git, it pipes the
+// output through $PAGER when stdout is a terminal and the output does not fit
+// on the screen, unless paging is disabled.
+func writeOutput(output []byte, usePager bool) error {
+	_, height, ok := terminalSize()
+	if !usePager || !ok || bytes.Count(output, []byte("\n")) < height {
+		_, err := os.Stdout.Write(output)
+		return err
+	}
+
+	pager := os.Getenv("PAGER")
+	if pager == "" {
+		pager = defaultPager
+	}
+
+	// Run the pager through the shell so PAGER may contain arguments
+	cmd := exec.Command("sh", "-c", pager)
+	cmd.Stdin = bytes.NewReader(output)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		// Fall back to plain output when the pager cannot be started
+		if _, ok := err.(*exec.ExitError); !ok {
+			_, err := os.Stdout.Write(output)
+			return err
+		}
+	}
+	return nil
+}
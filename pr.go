@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"yamldiff/compare"
+)
+
+// supportedExtensions lists the extensions of files compared by the pr
+// command. JSON is a subset of YAML, so JSON files are compared as well.
+var supportedExtensions = []string{".yaml", ".yml", ".json"}
+
+// isSupportedFile reports whether a file name has a supported extension
+func isSupportedFile(name string) bool {
+	ext := strings.ToLower(path.Ext(name))
+	for _, supported := range supportedExtensions {
+		if ext == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// compareFileAtRefs compares a changed file between two refs. Added and
+// deleted files are compared against an empty document, so their keys show
+// up as added or removed.
+func compareFileAtRefs(root, base, head string, file fileStatus, cfg *config) fileReport {
+	result := fileReport{Path: file.Path, OldPath: file.OldPath, Status: file.Status, Changes: []compare.Change{}}
+	prof := cfg.profileFor(file.Path)
+	if prof != nil {
+		result.Profile = prof.Name
+	}
+
+	oldPath := file.Path
+	if file.OldPath != "" {
+		oldPath = file.OldPath
+	}
+
+	doc1 := &compare.Document{Content: make(map[interface{}]interface{})}
+	doc2 := &compare.Document{Content: make(map[interface{}]interface{})}
+	var err error
+	if file.Status != "added" {
+		doc1, err = loadSourceFromGit(root, base, oldPath)
+		if err != nil {
+			result.Error = fmt.Sprintf("error loading %s at %s: %v", oldPath, base, err)
+			return result
+		}
+	}
+	if file.Status != "deleted" {
+		doc2, err = loadSourceFromGit(root, head, file.Path)
+		if err != nil {
+			result.Error = fmt.Sprintf("error loading %s at %s: %v", file.Path, head, err)
+			return result
+		}
+	}
+
+	result.Options = prof.options()
+	result.Options.MissingKeys = true
+	c := &compare.Comparison{Options: result.Options}
+	c.Compare(doc1, doc2)
+	result.Options = c.Options
+	result.Changes = append(result.Changes, c.Changes...)
+	return result
+}
+
+// loadSourceFromGit loads a file at a ref, treating an empty file as an empty
+// document
+func loadSourceFromGit(root, ref, relPath string) (*compare.Document, error) {
+	data, err := gitReadFile(root, ref, relPath)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := compare.Parse(data, false)
+	if err == nil && doc.Content == nil {
+		doc.Content = make(map[interface{}]interface{})
+	}
+	return doc, err
+}
+
+// newPRCmd returns the pr command reporting the semantic changes of all
+// YAML files changed between two refs
+func newPRCmd() *cobra.Command {
+	var base, head, outputFormat, saveResultFile string
+
+	cmd := &cobra.Command{
+		Use:   "pr",
+		Short: "Report the changes of all YAML files between two git refs.",
+		Long: `pr finds all YAML and JSON files changed on --head since it diverged from
+--base, like the file list of a pull request, and compares each of them
+semantically. The result is a single report with a summary per file, rendered
+as markdown (default), html or json with the -o flag.
+
+Unlike the two-file comparison, keys added or removed in a file are reported.
+Profiles from the configuration file (.yamldiff.yaml at the repository root,
+or --config) select comparison options per file:
+
+    profiles:
+      - name: helm
+        files: ["charts/**/*.yaml"]
+        ignore: [".metadata.labels", ".spec.template.metadata.annotations.checksum/*"]`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			root, err := gitRoot(".")
+			if err != nil {
+				return err
+			}
+
+			cfg, err := loadConfig(filepath.Join(root, defaultConfigFile), false)
+			if globalFlags.config != "" {
+				cfg, err = loadConfig(globalFlags.config, true)
+			}
+			if err != nil {
+				return fmt.Errorf("error loading configuration: %v", err)
+			}
+
+			files, err := gitChangedFiles(root, base, head)
+			if err != nil {
+				return err
+			}
+			verbosef(1, "%d files changed between %s and %s", len(files), base, head)
+
+			r := &report{Base: base, Head: head, Files: []fileReport{}}
+			for _, file := range files {
+				if isSupportedFile(file.Path) {
+					r.Files = append(r.Files, compareFileAtRefs(root, base, head, file, cfg))
+				}
+			}
+
+			if saveResultFile != "" {
+				inputs := []inputInfo{{Path: root, Ref: base}, {Path: root, Ref: head}}
+				if err := saveResult(saveResultFile, "pr", inputs, r); err != nil {
+					return fmt.Errorf("error saving result: %v", err)
+				}
+			}
+
+			return renderReport(os.Stdout, r, outputFormat, "")
+		},
+	}
+
+	cmd.Flags().StringVar(&base, "base", "main", "Ref the changes are compared against.")
+	cmd.Flags().StringVar(&head, "head", "HEAD", "Ref containing the changes.")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "markdown", "Set the report format ("+strings.Join(reportFormats, ", ")+").")
+	cmd.Flags().StringVar(&saveResultFile, "save-result", "", "Write the structured report to this JSON file for \"yamldiff render\".")
+	cmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions(reportFormats, cobra.ShellCompDirectiveNoFileComp))
+	return cmd
+}
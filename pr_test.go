@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"yamldiff/compare"
+)
+
+// gitRun runs a git command in a repository
+func gitRun(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	args = append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+	if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func TestPRChanges(t *testing.T) {
+	dir := filepath.Dir(gitRepo(t, "app.yaml", "replicas: 1\nname: app\n"))
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("old.yaml", "a: 1\n")
+	write("moved.yaml", "name: moved\nport: 80\nhost: example.com\nowner: team\n")
+	gitRun(t, dir, "add", ".")
+	gitRun(t, dir, "commit", "-q", "-m", "base")
+	gitRun(t, dir, "tag", "base")
+
+	write("app.yaml", "replicas: 2\nname: app\n")
+	write("new.yml", "b: 2\n")
+	write("notes.txt", "not yaml\n")
+	gitRun(t, dir, "rm", "-q", "old.yaml")
+	gitRun(t, dir, "mv", "moved.yaml", "renamed.yaml")
+	write("renamed.yaml", "name: moved\nport: 81\nhost: example.com\nowner: team\n")
+	gitRun(t, dir, "add", ".")
+	gitRun(t, dir, "commit", "-q", "-m", "head")
+
+	files, err := gitChangedFiles(dir, "base", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantFiles := []fileStatus{
+		{Path: "app.yaml", Status: "modified"},
+		{Path: "new.yml", Status: "added"},
+		{Path: "notes.txt", Status: "added"},
+		{Path: "old.yaml", Status: "deleted"},
+		{Path: "renamed.yaml", OldPath: "moved.yaml", Status: "renamed"},
+	}
+	if !reflect.DeepEqual(files, wantFiles) {
+		t.Fatalf("changed files = %+v, want %+v", files, wantFiles)
+	}
+
+	wantChanges := map[string][]compare.Change{
+		"app.yaml":     {{Path: ".replicas", Kind: compare.Modified, Left: 1, Right: 2, LeftRange: &compare.Range{Line: 1, Column: 1, EndLine: 1, EndColumn: 12}, RightRange: &compare.Range{Line: 1, Column: 1, EndLine: 1, EndColumn: 12}}},
+		"new.yml":      {{Path: ".b", Kind: compare.Added, Right: 2, RightRange: &compare.Range{Line: 1, Column: 1, EndLine: 1, EndColumn: 5}}},
+		"old.yaml":     {{Path: ".a", Kind: compare.Removed, Left: 1, LeftRange: &compare.Range{Line: 1, Column: 1, EndLine: 1, EndColumn: 5}}},
+		"renamed.yaml": {{Path: ".port", Kind: compare.Modified, Left: 80, Right: 81, LeftRange: &compare.Range{Line: 2, Column: 1, EndLine: 2, EndColumn: 9}, RightRange: &compare.Range{Line: 2, Column: 1, EndLine: 2, EndColumn: 9}}},
+	}
+	for _, file := range files {
+		if !isSupportedFile(file.Path) {
+			if file.Path != "notes.txt" {
+				t.Errorf("%s is not supported", file.Path)
+			}
+			continue
+		}
+		result := compareFileAtRefs(dir, "base", "HEAD", file, &config{})
+		if result.Error != "" {
+			t.Errorf("%s: %s", file.Path, result.Error)
+		}
+		if !reflect.DeepEqual(result.Changes, wantChanges[file.Path]) {
+			t.Errorf("%s: changes = %+v, want %+v", file.Path, result.Changes, wantChanges[file.Path])
+		}
+	}
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"strings"
+
+	"yamldiff/compare"
+)
+
+// fileReport holds the changes of a single file
+type fileReport struct {
+	Path    string            `json:"path"`
+	OldPath string            `json:"oldPath,omitempty"`
+	Status  string            `json:"status"`
+	Profile string            `json:"profile,omitempty"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Options compare.Options   `json:"options"`
+	Changes []compare.Change  `json:"changes"`
+	Error   string            `json:"error,omitempty"`
+}
+
+// report is a consolidated comparison of many files between two versions
+type report struct {
+	Base  string       `json:"base"`
+	Head  string       `json:"head"`
+	Files []fileReport `json:"files"`
+}
+
+// reportFormats lists the output formats a report can be rendered in
+var reportFormats = []string{"markdown", "html", "json"}
+
+// summary describes the number of changes of a file by kind
+func (f fileReport) summary() string {
+	if f.Error != "" {
+		return "error"
+	}
+	if len(f.Changes) == 0 {
+		return "no changes"
+	}
+
+	counts := make(map[compare.Kind]int)
+	for _, ch := range f.Changes {
+		counts[ch.Kind]++
+	}
+	var parts []string
+	for _, kind := range []compare.Kind{compare.Modified, compare.Added, compare.Removed} {
+		if counts[kind] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[kind], kind))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// totalChanges returns the number of changes across all files
+func (r *report) totalChanges() int {
+	total := 0
+	for _, f := range r.Files {
+		total += len(f.Changes)
+	}
+	return total
+}
+
+// renderReport writes a report in the given format. Changed strings are
+// highlighted by the inline diff mode, word or char, in markdown and HTML
+// reports, by word when mode is empty.
+func renderReport(w io.Writer, r *report, format, mode string) error {
+	if mode == "" {
+		mode = "word"
+	}
+
+	switch format {
+	case "markdown":
+		renderMarkdown(w, r, mode)
+		return nil
+	case "html":
+		return renderHTML(w, r, mode)
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r)
+	}
+	return fmt.Errorf("unknown output format %q, expected one of %s", format, strings.Join(reportFormats, ", "))
+}
+
+// changeSides returns the left and right values of a change as segments.
+// Modified strings are split into an inline diff by mode, word or char, so
+// the parts that changed can be highlighted; other values form a single
+// segment.
+func changeSides(ch compare.Change, mode string) (left, right []segment) {
+	s1, ok1 := ch.Left.(string)
+	s2, ok2 := ch.Right.(string)
+	if ch.Kind == compare.Modified && ok1 && ok2 && !strings.ContainsAny(s1+s2, "\r\n") {
+		for _, seg := range inlineDiff(s1, s2, mode) {
+			if seg.op != segmentInsert {
+				left = append(left, seg)
+			}
+			if seg.op != segmentDelete {
+				right = append(right, seg)
+			}
+		}
+		return left, right
+	}
+
+	if ch.Kind != compare.Added {
+		left = []segment{{segmentEqual, compare.InlineValue(ch.Left)}}
+	}
+	if ch.Kind != compare.Removed {
+		right = []segment{{segmentEqual, compare.InlineValue(ch.Right)}}
+	}
+	return left, right
+}
+
+// markdownEscaper escapes characters with a meaning in markdown tables
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "~", `\~`, "|", `\|`,
+	"<", "&lt;", ">", "&gt;", "[", `\[`, "]", `\]`,
+)
+
+// markdownCode renders text as an inline code span usable in a table cell
+func markdownCode(text string) string {
+	text = strings.ReplaceAll(text, "|", `\|`)
+	if strings.Contains(text, "`") {
+		return "`` " + text + " ``"
+	}
+	return "`" + text + "`"
+}
+
+// markdownSegments renders segments with removed words struck through and
+// added words in bold
+func markdownSegments(segments []segment) string {
+	if len(segments) == 1 && segments[0].op == segmentEqual {
+		return markdownCode(segments[0].text)
+	}
+
+	var b strings.Builder
+	for _, seg := range segments {
+		text := markdownEscaper.Replace(seg.text)
+		switch {
+		case seg.op == segmentEqual:
+			b.WriteString(text)
+		case strings.TrimSpace(seg.text) == "":
+			// Show changed whitespace instead of an empty highlight
+			visible, _ := visualizeString(seg.text)
+			b.WriteString(markdownCode(visible))
+		case seg.op == segmentDelete:
+			fmt.Fprintf(&b, "~~%s~~", text)
+		default:
+			fmt.Fprintf(&b, "**%s**", text)
+		}
+	}
+	return b.String()
+}
+
+// renderMarkdown writes a report as GitHub flavored markdown, highlighting
+// changed strings by the inline diff mode
+func renderMarkdown(w io.Writer, r *report, mode string) {
+	fmt.Fprintf(w, "# yamldiff report\n\n")
+	fmt.Fprintf(w, "Comparing %s with %s: %d files, %d changes.\n\n",
+		markdownCode(r.Base), markdownCode(r.Head), len(r.Files), r.totalChanges())
+	if len(r.Files) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "| File | Status | Changes |\n|------|--------|---------|\n")
+	for _, f := range r.Files {
+		fmt.Fprintf(w, "| %s | %s | %s |\n", markdownCode(f.Path), f.Status, f.summary())
+	}
+
+	for _, f := range r.Files {
+		fmt.Fprintf(w, "\n## %s\n\n", markdownCode(f.Path))
+		if f.OldPath != "" && f.Status != "modified" {
+			fmt.Fprintf(w, "File %s from %s.\n\n", f.Status, markdownCode(f.OldPath))
+		}
+		if f.Profile != "" {
+			fmt.Fprintf(w, "Profile: %s\n\n", markdownCode(f.Profile))
+		}
+		if len(f.Labels) > 0 {
+			fmt.Fprintf(w, "Labels: %s\n\n", markdownCode(formatLabels(f.Labels)))
+		}
+		if f.Error != "" {
+			fmt.Fprintf(w, "Error: %s\n", markdownEscaper.Replace(f.Error))
+			continue
+		}
+		if len(f.Changes) == 0 {
+			fmt.Fprintf(w, "No semantic changes.\n")
+			continue
+		}
+
+		fmt.Fprintf(w, "| Path | Change | %s | %s |\n|------|--------|------|------|\n",
+			markdownEscaper.Replace(r.Base), markdownEscaper.Replace(r.Head))
+		for _, ch := range f.Changes {
+			left, right := changeSides(ch, mode)
+			fmt.Fprintf(w, "| %s | %s | %s | %s |\n",
+				markdownCode(ch.Path), ch.Kind, markdownSegments(left), markdownSegments(right))
+		}
+	}
+}
+
+// htmlSegments renders segments with removed words in <del> and added words
+// in <ins> elements
+func htmlSegments(segments []segment) template.HTML {
+	if len(segments) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("<code>")
+	for _, seg := range segments {
+		text := html.EscapeString(seg.text)
+		switch seg.op {
+		case segmentDelete:
+			fmt.Fprintf(&b, "<del>%s</del>", text)
+		case segmentInsert:
+			fmt.Fprintf(&b, "<ins>%s</ins>", text)
+		default:
+			b.WriteString(text)
+		}
+	}
+	b.WriteString("</code>")
+	return template.HTML(b.String())
+}
+
+// htmlFuncs returns the functions of the HTML report template, rendering
+// the sides of changes with the inline diff mode
+func htmlFuncs(mode string) template.FuncMap {
+	return template.FuncMap{
+		"summary":      fileReport.summary,
+		"formatLabels": formatLabels,
+		"totalChanges": (*report).totalChanges,
+		"left": func(ch compare.Change) template.HTML {
+			left, _ := changeSides(ch, mode)
+			return htmlSegments(left)
+		},
+		"right": func(ch compare.Change) template.HTML {
+			_, right := changeSides(ch, mode)
+			return htmlSegments(right)
+		},
+	}
+}
+
+// htmlReport is the template of a self-contained HTML report
+var htmlReport = template.Must(template.New("report").Funcs(htmlFuncs("word")).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>yamldiff report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; vertical-align: top; }
+del { background: #fdd; }
+ins { background: #dfd; text-decoration: none; }
+</style>
+</head>
+<body>
+<h1>yamldiff report</h1>
+<p>Comparing <code>{{.Base}}</code> with <code>{{.Head}}</code>: {{len .Files}} files, {{totalChanges .}} changes.</p>
+{{- if .Files}}
+<table>
+<tr><th>File</th><th>Status</th><th>Changes</th></tr>
+{{- range $i, $f := .Files}}
+<tr><td><a href="#file-{{$i}}"><code>{{$f.Path}}</code></a></td><td>{{$f.Status}}</td><td>{{summary $f}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- range $i, $f := .Files}}
+<h2 id="file-{{$i}}"><code>{{$f.Path}}</code></h2>
+{{- if and $f.OldPath (ne $f.Status "modified")}}
+<p>File {{$f.Status}} from <code>{{$f.OldPath}}</code></p>
+{{- end}}
+{{- if $f.Profile}}
+<p>Profile: <code>{{$f.Profile}}</code></p>
+{{- end}}
+{{- if $f.Labels}}
+<p>Labels: <code>{{formatLabels $f.Labels}}</code></p>
+{{- end}}
+{{- if $f.Error}}
+<p>Error: {{$f.Error}}</p>
+{{- else if not $f.Changes}}
+<p>No semantic changes.</p>
+{{- else}}
+<table>
+<tr><th>Path</th><th>Change</th><th>{{$.Base}}</th><th>{{$.Head}}</th></tr>
+{{- range $f.Changes}}
+<tr><td><code>{{.Path}}</code></td><td>{{.Kind}}</td><td>{{left .}}</td><td>{{right .}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
+
+// renderHTML writes a report as a self-contained HTML page, highlighting
+// changed strings by the inline diff mode
+func renderHTML(w io.Writer, r *report, mode string) error {
+	t, err := htmlReport.Clone()
+	if err != nil {
+		return err
+	}
+	return t.Funcs(htmlFuncs(mode)).Execute(w, r)
+}
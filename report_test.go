@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"yamldiff/compare"
+)
+
+func TestRenderReportInlineDiff(t *testing.T) {
+	r := &report{Base: "a.yaml", Head: "b.yaml", Files: []fileReport{{
+		Path:    "b.yaml",
+		Status:  "modified",
+		Changes: []compare.Change{{Path: ".greeting", Kind: compare.Modified, Left: "hello world", Right: "help world"}},
+	}}}
+
+	tests := []struct {
+		format, mode string
+		want         string
+	}{
+		{"markdown", "", "| `.greeting` | modified | ~~hello~~ world | **help** world |"},
+		{"markdown", "char", "| `.greeting` | modified | hel~~lo~~ world | hel**p** world |"},
+		{"html", "", "<td><code><del>hello</del> world</code></td><td><code><ins>help</ins> world</code></td>"},
+		{"html", "char", "<td><code>hel<del>lo</del> world</code></td><td><code>hel<ins>p</ins> world</code></td>"},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := renderReport(&buf, r, tt.format, tt.mode); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), tt.want) {
+			t.Errorf("%s report with mode %q lacks %q:\n%s", tt.format, tt.mode, tt.want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	"yamldiff/compare"
+)
+
+// resultVersion is the format version of saved results
+const resultVersion = 1
+
+// outputFormats lists every format a comparison result can be printed in
+var outputFormats = append([]string{"yaml", "yamldiff"}, reportFormats...)
+
+// inputInfo describes one of the inputs of a comparison
+type inputInfo struct {
+	Path   string `json:"path,omitempty"`
+	Ref    string `json:"ref,omitempty"`
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// savedResult is the structured result of a comparison written by
+// --save-result, holding everything needed to render it again later
+type savedResult struct {
+	Version int         `json:"version"`
+	Command string      `json:"command"`
+	Created time.Time   `json:"created"`
+	Inputs  []inputInfo `json:"inputs"`
+	Report  *report     `json:"report"`
+}
+
+// fileInput returns the input metadata of a file in the working tree
+func fileInput(filePath string) inputInfo {
+	info := inputInfo{Path: filePath}
+	if data, err := ioutil.ReadFile(filePath); err == nil {
+		sum := sha256.Sum256(data)
+		info.SHA256 = hex.EncodeToString(sum[:])
+	}
+	return info
+}
+
+// saveResult writes a comparison result as JSON
+func saveResult(filePath, command string, inputs []inputInfo, r *report) error {
+	data, err := json.MarshalIndent(&savedResult{
+		Version: resultVersion,
+		Command: command,
+		Created: time.Now().UTC(),
+		Inputs:  inputs,
+		Report:  r,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filePath, append(data, '\n'), 0644)
+}
+
+// loadResult reads a result written by saveResult. Values are converted back
+// from JSON types to the types the YAML loader produces.
+func loadResult(filePath string) (*savedResult, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Numbers are decoded from their text, so large ints and floats written
+	// like ints keep their exact value and type
+	var result savedResult
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&result); err != nil {
+		return nil, err
+	}
+	if result.Version != resultVersion || result.Report == nil {
+		return nil, fmt.Errorf("unsupported result version %d", result.Version)
+	}
+
+	for i := range result.Report.Files {
+		changes := result.Report.Files[i].Changes
+		for j := range changes {
+			changes[j].Left = yamlValue(changes[j].Left)
+			changes[j].Right = yamlValue(changes[j].Right)
+		}
+	}
+	return &result, nil
+}
+
+// yamlValue converts a value decoded from JSON into the types the YAML loader
+// produces, so it renders the same way as a freshly compared value
+func yamlValue(val interface{}) interface{} {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		m := make(map[interface{}]interface{}, len(v))
+		for key, item := range v {
+			m[key] = yamlValue(item)
+		}
+		return m
+	case []interface{}:
+		for i, item := range v {
+			v[i] = yamlValue(item)
+		}
+		return v
+	case json.Number:
+		if !strings.ContainsAny(string(v), ".eE") {
+			if i, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+				if int64(int(i)) == i {
+					return int(i)
+				}
+				return i
+			}
+			if u, err := strconv.ParseUint(string(v), 10, 64); err == nil {
+				return u
+			}
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return string(v)
+	}
+	return val
+}
+
+// diffMapFromChanges rebuilds the map of differing values from the first file
+// out of a list of changes, parsing each path segment back into a YAML key
+func diffMapFromChanges(changes []compare.Change) map[interface{}]interface{} {
+	diffMap := make(map[interface{}]interface{})
+	for _, ch := range changes {
+		if ch.Kind == compare.Added {
+			continue
+		}
+
+		current := diffMap
+		segments := compare.SplitPath(ch.Path)
+		for i, segment := range segments {
+			// List elements keep their bracketed index or key as the name
+			var key interface{} = segment
+			if !strings.HasPrefix(segment, "[") {
+				if err := yaml.Unmarshal([]byte(segment), &key); err != nil || key == nil {
+					key = segment
+				}
+			}
+
+			if i == len(segments)-1 {
+				current[key] = ch.Left
+				break
+			}
+			next, ok := current[key].(map[interface{}]interface{})
+			if !ok {
+				next = make(map[interface{}]interface{})
+				current[key] = next
+			}
+			current = next
+		}
+	}
+	return diffMap
+}
+
+// printResult prints a comparison result in the given output format. The
+// differing values of the first file are rebuilt from the changes when
+// diffMap is nil.
+func (p *printer) printResult(r *report, diffMap map[interface{}]interface{}, format string) error {
+	switch format {
+	case "", "yaml", "yamldiff":
+	default:
+		return renderReport(p.w, r, format, p.inlineDiff)
+	}
+
+	for _, f := range r.Files {
+		// Results covering several files get a heading per file
+		if len(r.Files) > 1 && len(f.Labels) > 0 {
+			fmt.Fprintf(p.w, "\n# %s (%s)\n", f.Path, formatLabels(f.Labels))
+		} else if len(r.Files) > 1 {
+			fmt.Fprintf(p.w, "\n# %s\n", f.Path)
+		}
+		if f.Error != "" {
+			fmt.Fprintln(p.w, f.Error)
+			continue
+		}
+
+		p.leftName, p.rightName = f.OldPath, f.Path
+		if p.leftName == "" {
+			p.leftName = f.Path
+		}
+		if format != "yaml" {
+			for _, ch := range f.Changes {
+				p.printDifference(ch)
+			}
+		}
+		if format == "" {
+			continue
+		}
+
+		fileDiffMap := diffMap
+		if fileDiffMap == nil {
+			fileDiffMap = diffMapFromChanges(f.Changes)
+		}
+		if err := p.printYAML(fileDiffMap, format == "yamldiff"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newRenderCmd returns the render command printing a saved result again
+func newRenderCmd() *cobra.Command {
+	var outputFormat, lang string
+	var accessible, noPager bool
+
+	cmd := &cobra.Command{
+		Use:   "render result.json",
+		Short: "Render a result saved with --save-result.",
+		Long: `render prints a comparison result saved with --save-result in any output
+format without needing the original files, so a result computed once in CI
+can be published in several formats:
+
+    yamldiff pr --save-result result.json -o markdown > report.md
+    yamldiff render result.json -o html > report.html
+
+Values are saved as JSON, whose object keys are strings, so map keys that are
+not strings, like 80 or true, are rendered as the strings "80" and "true".`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			result, err := loadResult(args[0])
+			if err != nil {
+				return fmt.Errorf("error loading result: %v", err)
+			}
+
+			var output bytes.Buffer
+			p := &printer{w: &output, msg: catalogFor(lang), accessible: accessible}
+			p.color, _ = useColor(globalFlags.color)
+			if err := p.printResult(result.Report, nil, outputFormat); err != nil {
+				return err
+			}
+			return writeOutput(output.Bytes(), !noPager)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "", "Set the output format ("+strings.Join(outputFormats, ", ")+").")
+	cmd.Flags().StringVar(&lang, "lang", "", "Language for messages (en, de, es). Defaults to the LANG environment variable.")
+	cmd.Flags().BoolVar(&accessible, "accessible", false, "Start every output line with a descriptive word instead of relying on layout.")
+	cmd.Flags().BoolVar(&noPager, "no-pager", false, "Do not pipe output through $PAGER.")
+	cmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions(outputFormats, cobra.ShellCompDirectiveNoFileComp))
+	cmd.RegisterFlagCompletionFunc("lang", cobra.FixedCompletions([]string{"en", "de", "es"}, cobra.ShellCompDirectiveNoFileComp))
+	return cmd
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"yamldiff/compare"
+)
+
+func TestSaveResultRoundTrip(t *testing.T) {
+	values := []interface{}{
+		9007199254740993,
+		uint64(18446744073709551615),
+		1.0,
+		1.5,
+		1e21,
+		"1",
+		true,
+		nil,
+		[]interface{}{1.0, 2, "x"},
+		map[interface{}]interface{}{"a": 1.0, "b": map[interface{}]interface{}{"c": []interface{}{1}}},
+	}
+
+	var changes []compare.Change
+	for i, val := range values {
+		changes = append(changes, compare.Change{Path: compare.IndexPath(".v", i), Kind: compare.Modified, Left: val, Right: 0})
+	}
+	changes = append(changes, compare.Change{Path: ".added", Kind: compare.Added, Right: "new", RightRange: &compare.Range{Line: 3, Column: 8, EndLine: 3, EndColumn: 11}})
+
+	path := filepath.Join(t.TempDir(), "result.json")
+	r := &report{Base: "a.yaml", Head: "b.yaml", Files: []fileReport{{Path: "b.yaml", OldPath: "a.yaml", Status: "modified", Changes: changes}}}
+	if err := saveResult(path, "diff", []inputInfo{{Path: "a.yaml"}, {Path: "b.yaml"}}, r); err != nil {
+		t.Fatal(err)
+	}
+	result, err := loadResult(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := result.Report.Files[0].Changes
+	if len(loaded) != len(changes) {
+		t.Fatalf("loaded %d changes, want %d", len(loaded), len(changes))
+	}
+	for i, ch := range changes {
+		if !reflect.DeepEqual(loaded[i], ch) {
+			t.Errorf("change %d = %#v, want %#v", i, loaded[i], ch)
+		}
+	}
+}
+
+func TestSaveResultKeys(t *testing.T) {
+	// Map keys are JSON object keys, so keys of other types come back as strings
+	left := map[interface{}]interface{}{80: "http", true: "on", "name": "web"}
+	path := filepath.Join(t.TempDir(), "result.json")
+	r := &report{Files: []fileReport{{Changes: []compare.Change{{Path: ".a", Kind: compare.Removed, Left: left}}}}}
+	if err := saveResult(path, "diff", nil, r); err != nil {
+		t.Fatal(err)
+	}
+	result, err := loadResult(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[interface{}]interface{}{"80": "http", "true": "on", "name": "web"}
+	if got := result.Report.Files[0].Changes[0].Left; !reflect.DeepEqual(got, want) {
+		t.Errorf("loaded value = %#v, want %#v", got, want)
+	}
+}
+
+func TestDiffMapFromChanges(t *testing.T) {
+	changes := []compare.Change{
+		{Path: ".a.b", Kind: compare.Modified, Left: 1, Right: 2},
+		{Path: ".a.c", Kind: compare.Added, Right: 3},
+		{Path: ".list[name=web].image", Kind: compare.Modified, Left: "x", Right: "y"},
+		{Path: `."key.with.dots"`, Kind: compare.Removed, Left: true},
+		{Path: ".1", Kind: compare.Modified, Left: "one", Right: "two"},
+	}
+	want := map[interface{}]interface{}{
+		"a":             map[interface{}]interface{}{"b": 1},
+		"list":          map[interface{}]interface{}{"[name=web]": map[interface{}]interface{}{"image": "x"}},
+		"key.with.dots": true,
+		1:               "one",
+	}
+	if got := diffMapFromChanges(changes); !reflect.DeepEqual(got, want) {
+		t.Errorf("diffMapFromChanges() = %#v, want %#v", got, want)
+	}
+}
+
+func TestPrintDifference(t *testing.T) {
+	tests := []struct {
+		name       string
+		ch         compare.Change
+		accessible bool
+		want       string
+	}{
+		{
+			name: "modified",
+			ch:   compare.Change{Path: ".a", Kind: compare.Modified, Left: 1, Right: 2, LeftRange: &compare.Range{Line: 1, Column: 4, EndLine: 1, EndColumn: 5}, RightRange: &compare.Range{Line: 2, Column: 4, EndLine: 2, EndColumn: 5}},
+			want: "\nDifference at: .a (a.yaml:1 → b.yaml:2)\n  First file:  1\n  Second file: 2\n",
+		},
+		{
+			name: "added",
+			ch:   compare.Change{Path: ".b", Kind: compare.Added, Right: "x"},
+			want: "\nDifference at: .b (added)\n  Second file: x\n",
+		},
+		{
+			name: "removed collection",
+			ch:   compare.Change{Path: ".c", Kind: compare.Removed, Left: []interface{}{1.0}},
+			want: "\nDifference at: .c (removed)\n  First file:\n    - 1.0\n",
+		},
+		{
+			name:       "accessible modified",
+			ch:         compare.Change{Path: ".a", Kind: compare.Modified, Left: "true", Right: true},
+			accessible: true,
+			want:       "~ CHANGED: .a\n- BEFORE: First file: \"true\"\n+ AFTER: Second file: true\n",
+		},
+		{
+			name:       "accessible multi-line values",
+			ch:         compare.Change{Path: ".a", Kind: compare.Modified, Left: map[interface{}]interface{}{"b": 1, "c": 2}, Right: "one\ntwo"},
+			accessible: true,
+			want:       "~ CHANGED: .a\n- BEFORE: First file:\n- BEFORE: First file:   b: 1\n- BEFORE: First file:   c: 2\n+ AFTER: Second file: |-\n+ AFTER: Second file:     one\n+ AFTER: Second file:     two\n",
+		},
+		{
+			name:       "accessible added",
+			ch:         compare.Change{Path: ".b", Kind: compare.Added, Right: 1},
+			accessible: true,
+			want:       "+ ADDED: .b\n+ ADDED: Second file: 1\n",
+		},
+		{
+			name:       "accessible removed",
+			ch:         compare.Change{Path: ".b", Kind: compare.Removed, Left: 1},
+			accessible: true,
+			want:       "- REMOVED: .b\n- REMOVED: First file: 1\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			p := &printer{w: &buf, msg: catalogFor("en"), accessible: tt.accessible, leftName: "a.yaml", rightName: "b.yaml"}
+			p.printDifference(tt.ch)
+			if got := buf.String(); got != tt.want {
+				t.Errorf("printDifference() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"net"
+	"net/http"
+
+	"github.com/spf13/cobra"
+
+	"yamldiff/compare"
+)
+
+// webFiles holds the single-page UI served by the serve command
+//
+//go:embed web
+var webFiles embed.FS
+
+// maxRequestSize limits the size of a comparison request
+const maxRequestSize = 10 << 20
+
+// compareRequest is the body of a POST /api/compare request
+type compareRequest struct {
+	Left    string          `json:"left"`
+	Right   string          `json:"right"`
+	Profile string          `json:"profile,omitempty"`
+	Options compare.Options `json:"options"`
+}
+
+// compareResponse is the body of a successful response to a comparison
+// request
+type compareResponse struct {
+	Changes []compare.Change `json:"changes"`
+}
+
+// errorResponse is the body of a response to a failed request
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// profileInfo describes a profile to clients of the API
+type profileInfo struct {
+	Name    string          `json:"name"`
+	Options compare.Options `json:"options"`
+}
+
+// writeJSON writes a value as a JSON response
+func writeJSON(w http.ResponseWriter, status int, val interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(val); err != nil {
+		log.Printf("Error writing response: %v\n", err)
+	}
+}
+
+// newServeHandler returns the handler of the web UI and the JSON API
+func newServeHandler(cfg *config) http.Handler {
+	mux := http.NewServeMux()
+
+	static, _ := fs.Sub(webFiles, "web")
+	mux.Handle("GET /", http.FileServer(http.FS(static)))
+
+	mux.HandleFunc("GET /api/profiles", func(w http.ResponseWriter, r *http.Request) {
+		profiles := make([]profileInfo, 0, len(cfg.Profiles))
+		for i := range cfg.Profiles {
+			profiles = append(profiles, profileInfo{cfg.Profiles[i].Name, cfg.Profiles[i].options()})
+		}
+		writeJSON(w, http.StatusOK, profiles)
+	})
+
+	mux.HandleFunc("POST /api/compare", func(w http.ResponseWriter, r *http.Request) {
+		var req compareRequest
+		decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("invalid request: %v", err)})
+			return
+		}
+
+		options, err := withProfile(cfg, req.Profile, req.Options)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+			return
+		}
+		changes, err := compare.Documents([]byte(req.Left), []byte(req.Right), options)
+		if err != nil {
+			writeJSON(w, http.StatusUnprocessableEntity, errorResponse{Error: err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, compareResponse{append([]compare.Change{}, changes...)})
+	})
+
+	return mux
+}
+
+// newServeCmd returns the serve command running the web UI and JSON API
+func newServeCmd() *cobra.Command {
+	var addr string
+	var useGRPC bool
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve a web UI and JSON API for comparing YAML documents.",
+		Long: `serve starts an HTTP server with a web UI where two YAML documents can be
+pasted or uploaded and compared, with ignore patterns, list keys and a
+profile selected in the page. The options are kept in the URL, so a link
+shares the comparison settings. The UI is embedded in the binary.
+
+The same comparison is available as a JSON API:
+
+    GET  /api/profiles   profiles of the configuration file
+    POST /api/compare    {"left": "...", "right": "...", "profile": "helm",
+                          "options": {"ignore": [".metadata"],
+                                      "listKeys": {".spec.containers": "name"},
+                                      "missingKeys": true, "subset": false}}
+
+The response holds the list of changes, or an error message together with a
+4xx status. Profiles come from .yamldiff.yaml in the current directory or the
+file given with --config.
+
+With --grpc the server offers the gRPC service defined in
+yamldiffpb/yamldiff.proto instead, with a unary Compare method and a
+CompareBatch method streaming the result of each pair of a batch.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			cfg, err := loadConfig(defaultConfigFile, false)
+			if globalFlags.config != "" {
+				cfg, err = loadConfig(globalFlags.config, true)
+			}
+			if err != nil {
+				return fmt.Errorf("error loading configuration: %v", err)
+			}
+
+			if useGRPC {
+				listener, err := net.Listen("tcp", addr)
+				if err != nil {
+					return err
+				}
+				log.Printf("Serving yamldiff gRPC on %s\n", listener.Addr())
+				return newGRPCServer(cfg).Serve(listener)
+			}
+
+			log.Printf("Serving yamldiff on http://%s\n", addr)
+			return http.ListenAndServe(addr, newServeHandler(cfg))
+		},
+	}
+
+	cmd.Flags().StringVar(&addr, "addr", "localhost:8080", "Address to listen on.")
+	cmd.Flags().BoolVar(&useGRPC, "grpc", false, "Serve the gRPC service instead of the web UI and JSON API.")
+	return cmd
+}
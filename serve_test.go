@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestServeHandler(t *testing.T) {
+	cfg := &config{Profiles: []profile{{Name: "k8s", Ignore: []string{".metadata"}}}}
+	server := httptest.NewServer(newServeHandler(cfg))
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		want   string
+	}{
+		{
+			name:   "changes",
+			body:   `{"left": "a: 1\nb: 2\n", "right": "a: 2\nb: 2\n"}`,
+			status: http.StatusOK,
+			want:   `{"changes":[{"path":".a","kind":"modified","left":1,"right":2`,
+		},
+		{
+			name:   "profile",
+			body:   `{"left": "metadata: {uid: 1}\n", "right": "metadata: {uid: 2}\n", "profile": "k8s"}`,
+			status: http.StatusOK,
+			want:   `{"changes":[]}`,
+		},
+		{
+			name:   "unknown profile",
+			body:   `{"left": "", "right": "", "profile": "helm"}`,
+			status: http.StatusBadRequest,
+			want:   `"error":`,
+		},
+		{
+			name:   "unknown field",
+			body:   `{"left": "", "right": "", "files": []}`,
+			status: http.StatusBadRequest,
+			want:   `{"error":"invalid request: json: unknown field \"files\""}`,
+		},
+		{
+			name:   "invalid YAML",
+			body:   `{"left": "a: [", "right": ""}`,
+			status: http.StatusUnprocessableEntity,
+			want:   `"error":`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Post(server.URL+"/api/compare", "application/json", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			var body json.RawMessage
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.status || !strings.Contains(string(body), tt.want) {
+				t.Errorf("response = %d %s, want %d containing %s", resp.StatusCode, body, tt.status, tt.want)
+			}
+		})
+	}
+
+	resp, err := http.Get(server.URL + "/api/profiles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var profiles []profileInfo
+	if err := json.NewDecoder(resp.Body).Decode(&profiles); err != nil {
+		t.Fatal(err)
+	}
+	if len(profiles) != 1 || profiles[0].Name != "k8s" || profiles[0].Options.Ignore[0] != ".metadata" {
+		t.Errorf("profiles = %+v", profiles)
+	}
+
+	resp, err = http.Get(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		t.Errorf("GET / = %d %s, want the web UI", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+}
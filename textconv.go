@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"yamldiff/compare"
+)
+
+// flattenDocuments writes the leaf values of every document of a YAML stream
+// with flatten, separating documents with a --- line. A stream without
+// documents, like an empty file, prints nothing.
+func flattenDocuments(w io.Writer, data []byte) error {
+	docs, err := compare.DecodeDocuments(data)
+	if err != nil {
+		return err
+	}
+
+	for i, doc := range docs {
+		if i > 0 {
+			fmt.Fprintln(w, "---")
+		}
+		if len(doc.Content) == 0 {
+			continue
+		}
+		val, err := compare.NodeValue(doc.Content[0], false)
+		if err != nil {
+			return fmt.Errorf("document %d: %v", i+1, err)
+		}
+		flatten(w, "", val)
+	}
+	return nil
+}
+
+// flatten writes every leaf value of a YAML tree as a "path = value" line,
+// where the path of the whole tree is ".". Map keys are sorted so the output
+// does not depend on their order in the file, while lists keep their order.
+func flatten(w io.Writer, path string, val interface{}) {
+	label := path
+	if label == "" {
+		label = "."
+	}
+
+	switch v := val.(type) {
+	case map[interface{}]interface{}:
+		if len(v) == 0 {
+			fmt.Fprintf(w, "%s = {}\n", label)
+			return
+		}
+
+		paths := make(map[string]interface{}, len(v))
+		keys := make([]string, 0, len(v))
+		for key, item := range v {
+			keyPath := compare.JoinPath(path, key)
+			paths[keyPath] = item
+			keys = append(keys, keyPath)
+		}
+		sort.Strings(keys)
+		for _, keyPath := range keys {
+			flatten(w, keyPath, paths[keyPath])
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			fmt.Fprintf(w, "%s = []\n", label)
+			return
+		}
+
+		for i, item := range v {
+			flatten(w, compare.IndexPath(path, i), item)
+		}
+	default:
+		fmt.Fprintf(w, "%s = %s\n", label, compare.RenderScalar(val))
+	}
+}
+
+// newTextconvCmd returns the textconv command printing a YAML file in a
+// canonical flattened form for git's textconv attribute
+func newTextconvCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "textconv file.yaml",
+		Short: "Print a YAML file as sorted, flattened path = value lines.",
+		Long: `textconv prints every value of a YAML file on its own line as "path = value",
+sorted by path. Reordering keys or changing formatting does not change the
+output, so it is suited as a git textconv filter that makes "git diff",
+"git log -p" and GUI tools show changes per value:
+
+    git config diff.yaml.textconv "yamldiff textconv"
+    echo '*.yaml diff=yaml' >> .gitattributes
+
+The documents of a multi-document file are separated by a --- line, and a
+document holding a list or a single value is flattened like a map is, e.g.
+"[0].name = web" or ". = text". An empty file prints nothing.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			data, err := ioutil.ReadFile(args[0])
+			if err != nil {
+				return fmt.Errorf("error loading file: %v", err)
+			}
+			if err := flattenDocuments(os.Stdout, data); err != nil {
+				return fmt.Errorf("error loading file: %v", err)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"yamldiff/compare"
+)
+
+func TestFlatten(t *testing.T) {
+	doc, err := compare.Parse([]byte(`b:
+  list: [1, "2", {x: z}]
+  empty: {}
+  none: []
+a: "multi
+  line"
+text: |
+  one
+  two
+"key.dot": 1.0
+m: null
+`), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	flatten(&buf, "", doc.Content)
+	want := `."key.dot" = 1.0
+.a = multi line
+.b.empty = {}
+.b.list[0] = 1
+.b.list[1] = "2"
+.b.list[2].x = z
+.b.none = []
+.m = null
+.text = "one\ntwo\n"
+`
+	if buf.String() != want {
+		t.Errorf("flatten() =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestFlattenDocuments(t *testing.T) {
+	tests := []struct {
+		name, input, want string
+	}{
+		{"empty", "", ""},
+		{"only comments", "# nothing\n", ""},
+		{"empty map", "{}\n", ". = {}\n"},
+		{"list", "- name: web\n- db\n", "[0].name = web\n[1] = db\n"},
+		{"scalar", "text\n", ". = text\n"},
+		{"several documents", "a: 1\n---\n---\n- x\n", ".a = 1\n---\n. = null\n---\n[0] = x\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := flattenDocuments(&buf, []byte(tt.input)); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("flattenDocuments() = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
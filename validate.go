@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/spf13/cobra"
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"yamldiff/compare"
+)
+
+// problem is a reason a file cannot be compared as intended
+type problem struct {
+	// line is 0 when the position is unknown or part of the message
+	line    int
+	message string
+}
+
+// validateYAML checks that every document of a YAML stream can be compared:
+// it must parse, hold a map or nothing, define every key of a map once,
+// contain valid matchers when matchers are allowed and only use known
+// comment directives
+func validateYAML(data []byte, matchers bool) []problem {
+	docs, err := compare.DecodeDocuments(data)
+	if err != nil {
+		return []problem{{0, err.Error()}}
+	}
+
+	var problems []problem
+	for _, doc := range docs {
+		root := documentRoot(doc)
+		if root == nil {
+			continue
+		}
+		if compare.ResolveAlias(root).Kind != yamlv3.MappingNode {
+			problems = append(problems, problem{root.Line, "document is not a map"})
+			continue
+		}
+		if _, err := compare.NodeValue(root, matchers); err != nil {
+			problems = append(problems, problem{0, err.Error()})
+		}
+
+		var walk func(key, node *yamlv3.Node)
+		walk = func(key, node *yamlv3.Node) {
+			for _, d := range compare.NodeDirectives(key, node) {
+				if err := compare.CheckDirective(d); err != nil {
+					problems = append(problems, problem{d.Line, err.Error()})
+				}
+			}
+			switch node.Kind {
+			case yamlv3.MappingNode:
+				lines := make(map[string]int)
+				for i := 0; i+1 < len(node.Content); i += 2 {
+					key := node.Content[i]
+					if k, err := compare.ScalarValue(key); err == nil && key.Tag != "!!merge" {
+						if line, ok := lines[fmt.Sprint(k)]; ok {
+							problems = append(problems, problem{key.Line, fmt.Sprintf("key %s already defined on line %d", compare.InlineValue(k), line)})
+						}
+						lines[fmt.Sprint(k)] = key.Line
+					}
+					walk(key, node.Content[i+1])
+				}
+			case yamlv3.SequenceNode:
+				for _, item := range node.Content {
+					walk(nil, item)
+				}
+			}
+		}
+		walk(nil, root)
+
+		for _, d := range compare.StrayDirectives(doc) {
+			problems = append(problems, problem{d.Line, fmt.Sprintf("directive %s applies to no value, put it on or directly above a key or list element", d.Name)})
+		}
+	}
+	return problems
+}
+
+// newValidateCmd returns the validate command checking that YAML files can
+// be compared
+func newValidateCmd() *cobra.Command {
+	var subset bool
+
+	cmd := &cobra.Command{
+		Use:   "validate file...",
+		Short: "Check that YAML files can be compared.",
+		Long: `validate checks that YAML files can be compared: every document must parse,
+without duplicate keys, and hold a map. Comment directives must be known, have
+the values they need and sit on or directly above the value they apply to.
+With --subset, files are checked as the first file
+of a subset comparison, so their matchers must be valid.
+
+Every problem is printed as file:line: message, and validate exits with
+status 1 when a file has problems.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			failed := 0
+			for _, name := range args {
+				data, err := ioutil.ReadFile(name)
+				if err != nil {
+					return fmt.Errorf("error loading file: %v", err)
+				}
+
+				problems := validateYAML(data, subset)
+				for _, p := range problems {
+					if p.line > 0 {
+						fmt.Printf("%s:%d: %s\n", name, p.line, p.message)
+					} else {
+						fmt.Printf("%s: %s\n", name, p.message)
+					}
+				}
+				if len(problems) > 0 {
+					failed++
+				}
+			}
+
+			if failed > 0 {
+				fmt.Printf("\n%d of %d files have problems.\n", failed, len(args))
+				os.Exit(1)
+			}
+			verbosef(1, "all %d files are valid", len(args))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&subset, "subset", false, "Check the files as patterns of a subset comparison, which may contain matchers.")
+	return cmd
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateYAML(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		matchers bool
+		want     []problem
+	}{
+		{"valid", "a: 1 # yamldiff:ignore\nb: # yamldiff:list-key=name\n  - name: x\n", false, nil},
+		{"empty", "", false, nil},
+		{"several documents", "a: 1\n---\n- x\n", false, []problem{{3, "document is not a map"}}},
+		{"duplicate keys", "a: 1\nb:\n  c: 1\n  c: 2\na: 3\n", false, []problem{{4, "key c already defined on line 3"}, {5, "key a already defined on line 1"}}},
+		{"keys resolving alike", "yes: 1\ntrue: 2\n", false, []problem{{2, "key true already defined on line 1"}}},
+		{"directives", "a: 1 # yamldiff:ignore=x\nb: # yamldiff:list-key\n  - c: 1 # yamldiff:ignor\n", false, []problem{
+			{1, "directive ignore takes no value"},
+			{2, "directive list-key needs a field, e.g. list-key=name"},
+			{3, "unknown directive ignor"},
+		}},
+		{"stray directives", "# yamldiff:ignore\n\na:\n  b: 1\n  # yamldiff:ignore\nc: 1\n", false, []problem{
+			{0, "directive ignore applies to no value, put it on or directly above a key or list element"},
+			{4, "directive ignore applies to no value, put it on or directly above a key or list element"},
+		}},
+		{"matcher without matchers", "a: !regex '['\n", false, nil},
+		{"invalid matcher", "a: !regex '['\n", true, []problem{{0, "line 1: error parsing regexp: missing closing ]: `[`"}}},
+		{"syntax error", "a: [\n", false, []problem{{0, "yaml: line 1: did not find expected node content"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validateYAML([]byte(tt.input), tt.matchers); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("validateYAML() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
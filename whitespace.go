@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// invisibleSymbols maps characters that are hard to see in a terminal to the
+// symbol shown in their place by --show-whitespace
+var invisibleSymbols = map[rune]string{
+	'\t':     "→",
+	'\r':     "␍",
+	'\n':     "␊",
+	'\u00a0': "⍽",
+}
+
+// visualizeString renders s with whitespace and invisible characters replaced
+// by visible symbols. Trailing spaces on each line are shown as '·' and
+// zero-width or other non-printing characters as their code point. It also
+// returns the column each rune of s starts at, plus the total width.
+func visualizeString(s string) (string, []int) {
+	symbols := visibleRunes(s)
+	columns := make([]int, 0, len(symbols)+1)
+	var b strings.Builder
+	col := 0
+
+	for _, symbol := range symbols {
+		columns = append(columns, col)
+		b.WriteString(symbol)
+		col += utf8.RuneCountInString(symbol)
+	}
+
+	return b.String(), append(columns, col)
+}
+
+// visibleRunes returns the symbol shown for each rune of s by
+// visualizeString. Whether a space is trailing depends on the whole string,
+// so parts of a string are visualized by taking their runes' symbols.
+func visibleRunes(s string) []string {
+	runes := []rune(s)
+	symbols := make([]string, len(runes))
+	for i, r := range runes {
+		symbol, ok := invisibleSymbols[r]
+		switch {
+		case ok:
+		case r == ' ' && isTrailingSpace(runes[i:]):
+			symbol = "·"
+		case !unicode.IsPrint(r) && r != ' ':
+			symbol = fmt.Sprintf("<U+%04X>", r)
+		default:
+			symbol = string(r)
+		}
+		symbols[i] = symbol
+	}
+	return symbols
+}
+
+// isTrailingSpace reports whether the runes up to the next line break or the
+// end of the string are all spaces or tabs
+func isTrailingSpace(rest []rune) bool {
+	for _, r := range rest {
+		switch r {
+		case ' ', '\t':
+			continue
+		case '\r', '\n':
+			return true
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// differingRange returns the rune range that differs between a and b after
+// removing their common prefix and suffix. The range is [start, endA) in a
+// and [start, endB) in b.
+func differingRange(a, b []rune) (start, endA, endB int) {
+	for start < len(a) && start < len(b) && a[start] == b[start] {
+		start++
+	}
+
+	endA, endB = len(a), len(b)
+	for endA > start && endB > start && a[endA-1] == b[endB-1] {
+		endA--
+		endB--
+	}
+	return start, endA, endB
+}
+
+// printStrings prints two differing strings with whitespace made visible and
+// the differing character range marked below each of them
+func (p *printer) printStrings(prefix1, prefix2, s1, s2 string) {
+	runes1, runes2 := []rune(s1), []rune(s2)
+	start, end1, end2 := differingRange(runes1, runes2)
+
+	if p.accessible {
+		p.printVisibleString(prefix1, s1, -1, -1)
+		p.printVisibleString(prefix2, s2, -1, -1)
+
+		end := end1
+		if end2 > end {
+			end = end2
+		}
+		fmt.Fprintf(p.w, "^ %s %d-%d\n", p.msg.DiffersAt, start+1, end)
+		return
+	}
+
+	p.printVisibleString(prefix1, s1, start, end1)
+	p.printVisibleString(prefix2, s2, start, end2)
+}
+
+// printVisibleString prints a visualized string in quotes after its prefix and
+// underlines the runes in [start, end) with carets. A negative start disables
+// the underline.
+func (p *printer) printVisibleString(prefix, s string, start, end int) {
+	visible, columns := visualizeString(s)
+	fmt.Fprintln(p.w, p.truncate(prefix+`"`+visible+`"`))
+	if start < 0 {
+		return
+	}
+
+	// An insertion in the other string is marked with a single caret
+	width := columns[end] - columns[start]
+	if width < 1 {
+		width = 1
+	}
+	indent := visibleWidth(prefix) + 1 + columns[start]
+	fmt.Fprintln(p.w, p.truncate(strings.Repeat(" ", indent)+strings.Repeat("^", width)))
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"yamldiff/compare"
+)
+
+func TestVisualizeString(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    string
+		columns []int
+	}{
+		{"a b", "a b", []int{0, 1, 2, 3}},
+		{"a  ", "a··", []int{0, 1, 2, 3}},
+		{"a \r\n", "a·␍␊", []int{0, 1, 2, 3, 4}},
+		{"a\tb", "a→b", []int{0, 1, 2, 3}},
+		{"a b", "a⍽b", []int{0, 1, 2, 3}},
+		{"a​b", "a<U+200B>b", []int{0, 1, 9, 10}},
+	}
+
+	for _, tt := range tests {
+		got, columns := visualizeString(tt.s)
+		if got != tt.want || !reflect.DeepEqual(columns, tt.columns) {
+			t.Errorf("visualizeString(%q) = %q, %v, want %q, %v", tt.s, got, columns, tt.want, tt.columns)
+		}
+	}
+}
+
+func TestDifferingRange(t *testing.T) {
+	tests := []struct {
+		a, b              string
+		start, endA, endB int
+	}{
+		{"abc", "abc", 3, 3, 3},
+		{"abc", "axc", 1, 2, 2},
+		{"ab", "abc", 2, 2, 3},
+		{"aa", "aaa", 2, 2, 3},
+	}
+
+	for _, tt := range tests {
+		start, endA, endB := differingRange([]rune(tt.a), []rune(tt.b))
+		if start != tt.start || endA != tt.endA || endB != tt.endB {
+			t.Errorf("differingRange(%q, %q) = %d, %d, %d, want %d, %d, %d", tt.a, tt.b, start, endA, endB, tt.start, tt.endA, tt.endB)
+		}
+	}
+}
+
+func TestPrintDifferenceShowWhitespace(t *testing.T) {
+	ch := compare.Change{Path: ".a", Kind: compare.Modified, Left: "on ", Right: "on"}
+	tests := []struct {
+		name       string
+		accessible bool
+		want       string
+	}{
+		{
+			name: "carets",
+			want: "\nDifference at: .a\n  First file:  \"on·\"\n                  ^\n  Second file: \"on\"\n                  ^\n",
+		},
+		{
+			name:       "accessible",
+			accessible: true,
+			want:       "~ CHANGED: .a\n- BEFORE: First file: \"on·\"\n+ AFTER: Second file: \"on\"\n^ DIFFERS AT CHARACTERS: 3-3\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			p := &printer{w: &buf, msg: catalogFor("en"), accessible: tt.accessible, showWhitespace: true}
+			p.printDifference(ch)
+			if got := buf.String(); got != tt.want {
+				t.Errorf("printDifference() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
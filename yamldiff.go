@@ -1,161 +1,177 @@
-package main
-
-import (
-	"fmt"
-	"io/ioutil"
-	"log"
-	"os"
-	"reflect"
-
-	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v2"
-)
-
-// loadYAML loads a YAML file and returns its content as a map
-func loadYAML(filePath string) (map[interface{}]interface{}, error) {
-	data, err := ioutil.ReadFile(filePath)
-	if err != nil {
-		return nil, err
-	}
-
-	var content map[interface{}]interface{}
-	err = yaml.Unmarshal(data, &content)
-	if err != nil {
-		return nil, err
-	}
-
-	return content, nil
-}
-
-// compareMaps recursively compares two maps and calls printDifference when a difference is found.
-// compareMaps recursively compares two maps and calls printDifference when a difference is found.
-// It skips printing differences where a key is missing in one of the maps.
-func compareMaps(map1, map2 map[interface{}]interface{}, path string, diffMap map[interface{}]interface{}, print bool) {
-	for key := range map1 {
-		val1 := map1[key]
-		val2, ok := map2[key]
-		if !ok {
-			// Skip cases where the key is missing in the second map
-			continue
-		}
-
-		switch val1Typed := val1.(type) {
-		case map[interface{}]interface{}:
-			if nestedMap2, ok := val2.(map[interface{}]interface{}); ok {
-				newPath := path + "." + fmt.Sprint(key)
-				subDiffMap := make(map[interface{}]interface{})
-				compareMaps(val1Typed, nestedMap2, newPath, subDiffMap, print)
-				if len(subDiffMap) > 0 {
-					diffMap[key] = subDiffMap
-				}
-			} else {
-				if print && !reflect.DeepEqual(val1, val2) {
-					printDifference(path, key, val1, val2)
-				}
-				diffMap[key] = val1
-			}
-		default:
-			if !reflect.DeepEqual(val1, val2) {
-				if print {
-					printDifference(path, key, val1, val2)
-				}
-				diffMap[key] = val1
-			}
-		}
-	}
-
-	// Also check if there are keys in map2 that are missing in map1
-	for key := range map2 {
-		if _, ok := map1[key]; !ok {
-			// Skip cases where the key is missing in the first map
-			continue
-		}
-	}
-}
-
-// printDifference prints differing values along with their key paths
-func printDifference(path string, key interface{}, val1, val2 interface{}) {
-	fullPath := path + "." + fmt.Sprint(key)
-
-	// Format the output for better readability
-	fmt.Printf("\nDifference at: %s\n", fullPath)
-	fmt.Printf("  First file:  %v\n", val1)
-	fmt.Printf("  Second file: %v\n", val2)
-}
-
-// printYAML prints the content as YAML to the console with an optional header
-func printYAML(content map[interface{}]interface{}, diff bool) error {
-	data, err := yaml.Marshal(content)
-	if err != nil {
-		return err
-	}
-
-	if diff {
-		// ASCII header and line break
-		fmt.Println("\n==============================")
-		fmt.Println("Differing Values from First File")
-		fmt.Println("==============================\n")
-	}
-
-	fmt.Println(string(data))
-	return nil
-}
-
-func main() {
-	var outputFormat string
-
-	// Root command
-	var rootCmd = &cobra.Command{
-		Use:   "yamldiff [file1.yaml] [file2.yaml]",
-		Short: "Compare two YAML files and output the differences.",
-		Long: `yamldiff compares two YAML files and shows the differences.
-By default, it outputs the differences as YAML with additional formatting for clarity.
-You can choose other output format using the -o flag:
-
-- yaml: Outputs the differences as plain YAML without additional formatting.
-- yamldiff: Outputs the differences with an ASCII header and extra formatting for clarity.`,
-		Args: cobra.ExactArgs(2), // Expect exactly two arguments
-		Run: func(cmd *cobra.Command, args []string) {
-			file1 := args[0]
-			file2 := args[1]
-
-			data1, err := loadYAML(file1)
-			if err != nil {
-				log.Fatalf("Error loading first file: %v\n", err)
-			}
-
-			data2, err := loadYAML(file2)
-			if err != nil {
-				log.Fatalf("Error loading second file: %v\n", err)
-			}
-
-			diffMap := make(map[interface{}]interface{})
-
-			if outputFormat == "yaml" {
-				compareMaps(data1, data2, "", diffMap, false)
-				err := printYAML(diffMap, false)
-				if err != nil {
-					log.Fatalf("Error printing YAML: %v\n", err)
-				}
-			} else {
-				compareMaps(data1, data2, "", diffMap, true)
-
-				if outputFormat == "yamldiff" {
-					err := printYAML(diffMap, true)
-					if err != nil {
-						log.Fatalf("Error printing YAML: %v\n", err)
-					}
-				}
-			}
-		},
-	}
-
-	// Adding the output format flag
-	rootCmd.Flags().StringVarP(&outputFormat, "output", "o", "", "Set the output format (yaml, yamldiff).")
-
-	// Execute the root command
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
-	}
-}
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v2"
+
+	"yamldiff/compare"
+)
+
+// printer writes human-readable output using the selected message catalog
+type printer struct {
+	w          io.Writer
+	msg        messages
+	accessible bool
+	// width is the terminal width values are truncated to, 0 disables truncation
+	width int
+	// showWhitespace makes invisible characters in differing strings visible
+	showWhitespace bool
+	// inlineDiff is "word" or "char" to show changes within strings, empty to disable
+	inlineDiff string
+	// color highlights paths and the values of each file with ANSI colors
+	color bool
+	// leftName and rightName are the file names shown with the positions of
+	// changed values
+	leftName, rightName string
+}
+
+// printDifference prints differing values along with their key paths.
+// In accessible mode every line starts with a word describing it, so the
+// output can be followed without relying on layout or color.
+func (p *printer) printDifference(ch compare.Change) {
+	fullPath, val1, val2 := ch.Path, ch.Left, ch.Right
+
+	var prefix1, prefix2, prefix3 string
+	location := p.location(ch)
+	if p.accessible {
+		// The leading word names the kind of change
+		heading := p.paint(colorYellow, "~ "+p.msg.Changed)
+		switch ch.Kind {
+		case compare.Added:
+			heading = p.paint(colorGreen, "+ "+p.msg.Added)
+		case compare.Removed:
+			heading = p.paint(colorRed, "- "+p.msg.Removed)
+		}
+		fmt.Fprintf(p.w, "%s %s%s\n", heading, p.paint(colorBold, fullPath), location)
+
+		// Values of a changed value are the one before and after the change,
+		// the single value of an added or removed key is what was added or
+		// removed
+		before, after := p.msg.Before, p.msg.After
+		if ch.Kind != compare.Modified {
+			before, after = p.msg.Removed, p.msg.Added
+		}
+		prefix1 = p.paint(colorRed, fmt.Sprintf("- %s %s", before, p.msg.FirstFile)) + " "
+		prefix2 = p.paint(colorGreen, fmt.Sprintf("+ %s %s", after, p.msg.SecondFile)) + " "
+		prefix3 = fmt.Sprintf("~ %s ", p.msg.Changes)
+	} else {
+		// Format the output for better readability, aligning all values
+		width := 0
+		for _, label := range []string{p.msg.FirstFile, p.msg.SecondFile, p.msg.Changes} {
+			if n := utf8.RuneCountInString(label); n > width {
+				width = n
+			}
+		}
+		label := func(color, text string) string {
+			return "  " + p.paint(color, text) + strings.Repeat(" ", width-utf8.RuneCountInString(text)+1)
+		}
+		kind := ""
+		switch ch.Kind {
+		case compare.Added:
+			kind = " " + p.paint(colorGreen, p.msg.KeyAdded)
+		case compare.Removed:
+			kind = " " + p.paint(colorRed, p.msg.KeyRemoved)
+		}
+		fmt.Fprintf(p.w, "\n%s %s%s%s\n", p.paint(colorYellow, p.msg.DifferenceAt), p.paint(colorBold, fullPath), kind, location)
+		prefix1 = label(colorRed, p.msg.FirstFile)
+		prefix2 = label(colorGreen, p.msg.SecondFile)
+		prefix3 = label("", p.msg.Changes)
+	}
+
+	// Keys present in only one file have a single value to show
+	switch ch.Kind {
+	case compare.Added:
+		p.printValue(prefix2, val2)
+		return
+	case compare.Removed:
+		p.printValue(prefix1, val1)
+		return
+	}
+
+	s1, ok1 := val1.(string)
+	s2, ok2 := val2.(string)
+	if ok1 && ok2 && p.showWhitespace {
+		p.printStrings(prefix1, prefix2, s1, s2)
+	} else {
+		p.printValue(prefix1, val1)
+		p.printValue(prefix2, val2)
+	}
+
+	if ok1 && ok2 && p.inlineDiff != "" {
+		p.printInlineDiff(prefix3, s1, s2)
+	}
+}
+
+// location describes where the values of a change are found, such as
+// " (a.yaml:12 → b.yaml:15)", or returns an empty string when the positions
+// are unknown
+func (p *printer) location(ch compare.Change) string {
+	var parts []string
+	if ch.LeftRange != nil {
+		parts = append(parts, fmt.Sprintf("%s:%d", p.leftName, ch.LeftRange.Line))
+	}
+	if ch.RightRange != nil {
+		parts = append(parts, fmt.Sprintf("%s:%d", p.rightName, ch.RightRange.Line))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, " → ") + ")"
+}
+
+// printValue prints a value rendered as YAML after its prefix. Collections
+// start on the next line and continuation lines are indented below the
+// prefix, or in accessible mode repeat it so every line tells which value it
+// belongs to. Lines are truncated with an ellipsis when they would not fit in
+// the terminal width.
+func (p *printer) printValue(prefix string, val interface{}) {
+	lines := strings.Split(compare.RenderValue(val), "\n")
+	if compare.IsCollection(val) {
+		fmt.Fprintln(p.w, strings.TrimRight(prefix, " "))
+	} else {
+		fmt.Fprintln(p.w, p.truncate(prefix+lines[0]))
+		lines = lines[1:]
+	}
+
+	indent := "    "
+	if p.accessible {
+		indent = prefix + "  "
+	}
+	for _, line := range lines {
+		fmt.Fprintln(p.w, p.truncate(indent+line))
+	}
+}
+
+// truncate shortens a line to the terminal width, ending it with an ellipsis
+func (p *printer) truncate(line string) string {
+	if p.width <= 1 || visibleWidth(line) <= p.width {
+		return line
+	}
+	return cutVisible(line, p.width-1) + "…"
+}
+
+// printYAML prints the content as YAML to the console with an optional header
+func (p *printer) printYAML(content map[interface{}]interface{}, diff bool) error {
+	data, err := yaml.Marshal(content)
+	if err != nil {
+		return err
+	}
+
+	if diff {
+		if p.accessible {
+			fmt.Fprintf(p.w, "\n%s:\n", p.msg.DiffHeader)
+		} else {
+			// ASCII header and line break
+			fmt.Fprintln(p.w, "\n==============================")
+			fmt.Fprintln(p.w, p.msg.DiffHeader)
+			fmt.Fprint(p.w, "==============================\n\n")
+		}
+	}
+
+	fmt.Fprintln(p.w, string(data))
+	return nil
+}
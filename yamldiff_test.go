@@ -0,0 +1,29 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"yamldiff/compare"
+)
+
+func TestPrintValueWidth(t *testing.T) {
+	var buf bytes.Buffer
+	p := &printer{w: &buf, width: 12}
+	p.printValue("  First: ", map[interface{}]interface{}{"key": "a long value", "b": 1})
+	p.printValue("  Second: ", "a long value")
+
+	want := "  First:\n    b: 1\n    key: a …\n  Second: a…\n"
+	if buf.String() != want {
+		t.Errorf("output =\n%q\nwant\n%q", buf.String(), want)
+	}
+}
+
+// changePaths returns the paths of changes
+func changePaths(changes []compare.Change) []string {
+	var paths []string
+	for _, ch := range changes {
+		paths = append(paths, ch.Path)
+	}
+	return paths
+}
@@ -0,0 +1,5 @@
+// Package yamldiffpb holds the protocol buffer messages and gRPC service of
+// the yamldiff server, generated from yamldiff.proto.
+package yamldiffpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative yamldiff.proto
@@ -0,0 +1,172 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: yamldiff.proto
+
+// The yamldiff service compares YAML documents semantically and returns the
+// differences as structured changes.
+
+package yamldiffpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Diff_Compare_FullMethodName      = "/yamldiff.v1.Diff/Compare"
+	Diff_CompareBatch_FullMethodName = "/yamldiff.v1.Diff/CompareBatch"
+)
+
+// DiffClient is the client API for Diff service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type DiffClient interface {
+	// Compare compares two YAML documents.
+	Compare(ctx context.Context, in *CompareRequest, opts ...grpc.CallOption) (*CompareResponse, error)
+	// CompareBatch compares many pairs of documents concurrently and streams a
+	// result for each pair as soon as it is done.
+	CompareBatch(ctx context.Context, in *CompareBatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BatchResult], error)
+}
+
+type diffClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDiffClient(cc grpc.ClientConnInterface) DiffClient {
+	return &diffClient{cc}
+}
+
+func (c *diffClient) Compare(ctx context.Context, in *CompareRequest, opts ...grpc.CallOption) (*CompareResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CompareResponse)
+	err := c.cc.Invoke(ctx, Diff_Compare_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *diffClient) CompareBatch(ctx context.Context, in *CompareBatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BatchResult], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Diff_ServiceDesc.Streams[0], Diff_CompareBatch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[CompareBatchRequest, BatchResult]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Diff_CompareBatchClient = grpc.ServerStreamingClient[BatchResult]
+
+// DiffServer is the server API for Diff service.
+// All implementations must embed UnimplementedDiffServer
+// for forward compatibility.
+type DiffServer interface {
+	// Compare compares two YAML documents.
+	Compare(context.Context, *CompareRequest) (*CompareResponse, error)
+	// CompareBatch compares many pairs of documents concurrently and streams a
+	// result for each pair as soon as it is done.
+	CompareBatch(*CompareBatchRequest, grpc.ServerStreamingServer[BatchResult]) error
+	mustEmbedUnimplementedDiffServer()
+}
+
+// UnimplementedDiffServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedDiffServer struct{}
+
+func (UnimplementedDiffServer) Compare(context.Context, *CompareRequest) (*CompareResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Compare not implemented")
+}
+func (UnimplementedDiffServer) CompareBatch(*CompareBatchRequest, grpc.ServerStreamingServer[BatchResult]) error {
+	return status.Errorf(codes.Unimplemented, "method CompareBatch not implemented")
+}
+func (UnimplementedDiffServer) mustEmbedUnimplementedDiffServer() {}
+func (UnimplementedDiffServer) testEmbeddedByValue()              {}
+
+// UnsafeDiffServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DiffServer will
+// result in compilation errors.
+type UnsafeDiffServer interface {
+	mustEmbedUnimplementedDiffServer()
+}
+
+func RegisterDiffServer(s grpc.ServiceRegistrar, srv DiffServer) {
+	// If the following call pancis, it indicates UnimplementedDiffServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Diff_ServiceDesc, srv)
+}
+
+func _Diff_Compare_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompareRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DiffServer).Compare(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Diff_Compare_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DiffServer).Compare(ctx, req.(*CompareRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Diff_CompareBatch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(CompareBatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DiffServer).CompareBatch(m, &grpc.GenericServerStream[CompareBatchRequest, BatchResult]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Diff_CompareBatchServer = grpc.ServerStreamingServer[BatchResult]
+
+// Diff_ServiceDesc is the grpc.ServiceDesc for Diff service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Diff_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "yamldiff.v1.Diff",
+	HandlerType: (*DiffServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Compare",
+			Handler:    _Diff_Compare_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "CompareBatch",
+			Handler:       _Diff_CompareBatch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "yamldiff.proto",
+}